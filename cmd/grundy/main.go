@@ -5,14 +5,19 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/stephen-fox/grundy/internal/cyberdaemon"
 	"github.com/stephen-fox/grundy/internal/installer"
+	"github.com/stephen-fox/grundy/internal/metadata"
 	"github.com/stephen-fox/grundy/internal/results"
 	"github.com/stephen-fox/grundy/internal/settings"
 	"github.com/stephen-fox/grundy/internal/shortman"
@@ -37,6 +42,14 @@ const (
 	installArg            = "install"
 	uninstallArg          = "uninstall"
 	daemonCommandArg      = "daemon"
+	syncArg               = "sync"
+	planArg               = "plan"
+	resultsFormatArg      = "results-format"
+	resultsFilePathArg    = "results-file"
+	restoreShortcutsArg   = "restore-shortcuts"
+	statusArg             = "status"
+	listArg               = "list"
+	retroArchCoresArg     = "retroarch-cores"
 	appSettingsDirPathArg = "settings"
 	helpArg               = "h"
 )
@@ -44,6 +57,11 @@ const (
 var (
 	daemonId string
 	version  string
+
+	resultsFormat = results.TextFormat
+	resultsOutput io.Writer
+
+	lastSteamRootDirPath string
 )
 
 type application struct {
@@ -78,6 +96,22 @@ type settingsState struct {
 	app              settings.AppSettings
 	launchers        settings.LaunchersSettings
 	knownGames       settings.KnownGamesSettings
+	pendingChanges   *steamw.PendingChanges
+	metadata         metadata.DatProvider
+}
+
+func (o *settingsState) load() error {
+	err := o.app.Reload(path.Join(o.configDirPath, o.app.Filename("")))
+	if err != nil {
+		return errors.New("Failed to load application settings - " + err.Error())
+	}
+
+	err = o.launchers.Reload(path.Join(o.configDirPath, o.launchers.Filename("")))
+	if err != nil {
+		return errors.New("Failed to load launchers settings - " + err.Error())
+	}
+
+	return nil
 }
 
 func (o *settingsState) reload(updatedPaths []string) map[configReloadAction]configReloadAction {
@@ -105,7 +139,16 @@ func (o *settingsState) reload(updatedPaths []string) map[configReloadAction]con
 			actions[updateGameCollections] = updateGameCollections
 			actions[refreshKnownGames] = refreshKnownGames
 		default:
-			continue
+			if !strings.EqualFold(path.Ext(filePath), metadata.DatFileExtension) {
+				continue
+			}
+
+			err := o.metadata.Reload()
+			if err != nil {
+				logError("Failed to load DAT files -", err.Error())
+			}
+
+			actions[refreshKnownGames] = refreshKnownGames
 		}
 	}
 
@@ -120,6 +163,25 @@ func main() {
 	daemonCommand := flag.String(daemonCommandArg, "",
 		"Manage the application's daemon with the following commands:\n" +
 		cyberdaemon.CommandsString())
+	doSync := flag.Bool(syncArg, false, "Create or update shortcuts for all game collections and then exit")
+	doPlan := flag.Bool(planArg, false, "Show the shortcut changes that would be made for all game collections " +
+		"without making them, and then exit")
+	resultsFormatValue := flag.String(resultsFormatArg, results.TextFormat.String(),
+		"The format to write results in. Results are written to stdout unless '-" +
+		resultsFilePathArg + "' is specified. Supported formats:\n'" +
+		strings.Join(results.Formats(), "', '") + "'")
+	resultsFilePath := flag.String(resultsFilePathArg, "",
+		"The file to append results to. The '" + results.JsonFormat.String() + "' results format " +
+		"cannot be used with a file - use '" + results.JsonLinesFormat.String() + "' instead")
+	restoreShortcutsPath := flag.String(restoreShortcutsArg, "",
+		"Replace a Steam user's shortcuts file with the specified backup and then exit. " +
+		"Backups are stored in:\n'" + settings.ShortcutsBackupsDir(settings.DirPath()) + "'")
+	doStatus := flag.Bool(statusArg, false, "Show whether Steam is running and the shortcut changes " +
+		"that are waiting for Steam to exit, and then exit")
+	doList := flag.Bool(listArg, false, "List the known games along with their Steam shortcut " +
+		"app IDs and legacy grid IDs, and then exit")
+	retroArchLauncherName := flag.String(retroArchCoresArg, "",
+		"List the cores that are installed for the specified RetroArch launcher")
 	help := flag.Bool(helpArg, false, "Show this help information")
 
 	flag.Parse()
@@ -179,6 +241,33 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *doStatus {
+		err := printStatus(*appSettingsDirPath)
+		if err != nil {
+			logFatal(err.Error())
+		}
+
+		os.Exit(0)
+	}
+
+	if *doList {
+		err := printKnownGames(*appSettingsDirPath)
+		if err != nil {
+			logFatal(err.Error())
+		}
+
+		os.Exit(0)
+	}
+
+	if len(strings.TrimSpace(*retroArchLauncherName)) > 0 {
+		err := printRetroArchCores(*appSettingsDirPath, *retroArchLauncherName)
+		if err != nil {
+			logFatal(err.Error())
+		}
+
+		os.Exit(0)
+	}
+
 	appMutex, err := ipcm.NewMutex(ipcm.MutexConfig{
 		Resource: path.Join(settings.InternalFilesDir(*appSettingsDirPath), "lock"),
 	})
@@ -200,10 +289,37 @@ func main() {
 
 	log.SetOutput(io.MultiWriter(logFile, os.Stderr))
 
-	currentSettings, err := setupSettings(*appSettingsDirPath)
+	resultsFile, err := setupResultsOutput(*resultsFormatValue, *resultsFilePath)
 	if err != nil {
 		logFatal(err.Error())
 	}
+	if resultsFile != nil {
+		defer resultsFile.Close()
+	}
+
+	if len(strings.TrimSpace(*restoreShortcutsPath)) > 0 {
+		err := restoreShortcuts(*appSettingsDirPath, *restoreShortcutsPath)
+		if err != nil {
+			logFatal(err.Error())
+		}
+
+		os.Exit(0)
+	}
+
+	currentSettings, err := setupSettings(*appSettingsDirPath, !*doPlan)
+	if err != nil {
+		logFatal(err.Error())
+	}
+
+	if *doSync || *doPlan {
+		exitCode := syncGameCollections(currentSettings, *doPlan)
+		appMutex.Unlock()
+		logFile.Close()
+		if resultsFile != nil {
+			resultsFile.Close()
+		}
+		os.Exit(exitCode)
+	}
 
 	app := &application{
 		settings: currentSettings,
@@ -216,7 +332,51 @@ func main() {
 	}
 }
 
-func setupSettings(settingsDirPath string) (*settingsState, error) {
+// setupResultsOutput configures where results are written to in addition
+// to the application log. The returned file is nil if results are not
+// being written to a file.
+func setupResultsOutput(format string, filePath string) (*os.File, error) {
+	isSupported := false
+
+	for _, f := range results.Formats() {
+		if f == format {
+			isSupported = true
+			break
+		}
+	}
+
+	if !isSupported {
+		return nil, errors.New("Unsupported results format '" + format + "'")
+	}
+
+	resultsFormat = results.Format(format)
+
+	if len(strings.TrimSpace(filePath)) > 0 {
+		// Appending an array for each batch of results would
+		// leave the file with several JSON documents in it.
+		if resultsFormat == results.JsonFormat {
+			return nil, errors.New("The '" + results.JsonFormat.String() + "' results format cannot be used with '-" +
+				resultsFilePathArg + "' - use '" + results.JsonLinesFormat.String() + "' instead")
+		}
+
+		f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, errors.New("Failed to open results file - " + err.Error())
+		}
+
+		resultsOutput = f
+
+		return f, nil
+	}
+
+	if resultsFormat != results.TextFormat {
+		resultsOutput = os.Stdout
+	}
+
+	return nil, nil
+}
+
+func setupSettings(settingsDirPath string, cleanupKnownGames bool) (*settingsState, error) {
 	launchers := settings.NewLaunchersSettings()
 	launchers.AddOrUpdate(settings.NewLauncher())
 	app := settings.NewAppSettings()
@@ -250,9 +410,27 @@ func setupSettings(settingsDirPath string) (*settingsState, error) {
 		return nil, errors.New("Failed to create internal settings directory path - " + err.Error())
 	}
 
+	// The application settings are needed to find Steam's data
+	// before the settings directory watcher has started.
+	err = app.Reload(path.Join(settingsDirPath, app.Filename("")))
+	if err != nil {
+		logError("Failed to load application settings -", err.Error())
+	}
+
+	pendingChanges, err := steamw.LoadPendingChanges(settings.PendingChangesFilePath(settingsDirPath))
+	if err != nil {
+		logError("Failed to load pending shortcut changes -", err.Error())
+	}
+
+	dats := metadata.NewDatProvider(settingsDirPath)
+	err = dats.Reload()
+	if err != nil {
+		logError("Failed to load DAT files -", err.Error())
+	}
+
 	knownGames, loaded := settings.LoadOrCreateKnownGamesSettings(internalDirPath)
-	if loaded {
-		err := cleanupKnownGameShortcuts(knownGames)
+	if loaded && cleanupKnownGames {
+		err := cleanupKnownGameShortcuts(knownGames, app, settingsDirPath, pendingChanges)
 		if err != nil {
 			logError("Failed to cleanup known game shortcuts -", err.Error())
 		}
@@ -261,7 +439,7 @@ func setupSettings(settingsDirPath string) (*settingsState, error) {
 	configDirWatcherConfig := watcher.Config{
 		ScanFunc:     watcher.ScanFilesInDirectory,
 		RootDirPath:  settingsDirPath,
-		ScanCriteria: []string{settings.FileExtension},
+		ScanCriteria: []string{settings.FileExtension, metadata.DatFileExtension},
 		Changes:      make(chan watcher.Change),
 	}
 
@@ -277,6 +455,8 @@ func setupSettings(settingsDirPath string) (*settingsState, error) {
 		app:              app,
 		launchers:        launchers,
 		knownGames:       knownGames,
+		pendingChanges:   pendingChanges,
+		metadata:         dats,
 	}, nil
 }
 
@@ -285,49 +465,305 @@ func setupSettings(settingsDirPath string) (*settingsState, error) {
 //
 // This function is not in 'shortman' because it is more efficient to run it
 // early on (before we create a 'ShortcutManager'.
-func cleanupKnownGameShortcuts(knownGames settings.KnownGamesSettings) error {
+func cleanupKnownGameShortcuts(knownGames settings.KnownGamesSettings, app settings.AppSettings, settingsDirPath string, pendingChanges *steamw.PendingChanges) error {
 	gameDirPathsToGameNames := knownGames.DisownNonExistingGames()
 	if len(gameDirPathsToGameNames) == 0 {
 		return nil
 	}
 
-	info, err := steamw.NewSteamDataInfo()
+	info, err := newSteamDataInfo(app, settingsDirPath)
 	if err != nil {
 		return err
 	}
 
-	for _, gameName := range gameDirPathsToGameNames {
-		config := steamw.DeleteShortcutConfig{
-			GameName:            gameName,
-			Info:                info,
-			SkipGridImageDelete: true,
-		}
+	batch := steamw.NewBatch()
+
+	for dirPath, gameName := range gameDirPathsToGameNames {
+		batch.DeleteShortcut(steamw.DeleteShortcutConfig{
+			ShortcutId:  steamw.GameShortcutId(dirPath),
+			GameName:    gameName,
+			GameDirPath: dirPath,
+			Info:        info,
+		})
+	}
+
+	logResults(batch.FlushOrDefer(pendingChanges, info))
+
+	return nil
+}
+
+// newSteamDataInfo gets information about Steam's data, logging the
+// Steam data directory whenever it changes.
+func newSteamDataInfo(app settings.AppSettings, settingsDirPath string) (steamw.DataInfo, error) {
+	info, err := steamw.NewSteamDataInfo(app.SteamRootDirPath())
+	if err != nil {
+		return info, err
+	}
+
+	info.BackupsDirPath = settings.ShortcutsBackupsDir(settingsDirPath)
+	info.ImageCacheDirPath = settings.ImageCacheDir(settingsDirPath)
+
+	rootDirPath := info.DataLocations.RootDirPath()
+	if rootDirPath != lastSteamRootDirPath {
+		logInfo("Using Steam data directory '" + rootDirPath + "' (" + info.RootSource.String() + ")")
+		lastSteamRootDirPath = rootDirPath
+	}
+
+	return info, nil
+}
+
+// restoreShortcuts replaces a Steam user's shortcuts file with a backup.
+func restoreShortcuts(settingsDirPath string, backupFilePath string) error {
+	app := settings.NewAppSettings()
+
+	err := app.Reload(path.Join(settingsDirPath, app.Filename("")))
+	if err != nil {
+		logError("Failed to load application settings -", err.Error())
+	}
+
+	info, err := newSteamDataInfo(app, settingsDirPath)
+	if err != nil {
+		return errors.New("Failed to get Steam info - " + err.Error())
+	}
+
+	steamUserId, err := steamw.RestoreShortcutsBackup(info, backupFilePath)
+	if err != nil {
+		return errors.New("Failed to restore shortcuts backup - " + err.Error())
+	}
+
+	logInfo("Restored shortcuts for Steam user " + steamUserId + " from '" + backupFilePath + "'")
+
+	return nil
+}
+
+// waitForSteamExit makes any pending shortcut changes once Steam exits.
+// Steam is only waited on if the application settings allow asking it to
+// exit. Otherwise, the changes are left for the daemon or the next sync.
+func waitForSteamExit(currentSettings *settingsState, steamDataInfo steamw.DataInfo) []results.Result {
+	pendingChanges := currentSettings.pendingChanges
+	if pendingChanges.IsEmpty() {
+		return nil
+	}
+
+	if !currentSettings.app.CloseSteamForChanges() {
+		logWarn(strconv.Itoa(len(pendingChanges.Changes())) + " shortcut change(s) will be made " +
+			"after Steam exits by the daemon or the next sync")
+		return nil
+	}
+
+	logInfo("Asking Steam to exit so that pending shortcut changes can be made...")
+
+	err := steamw.RequestSteamShutdown()
+	if err != nil {
+		logError(err.Error())
+		return nil
+	}
 
-		deleteResults := steamw.DeleteShortcut(config)
+	deadline := time.Now().Add(time.Minute)
 
-		for i := range deleteResults {
-			logResult(deleteResults[i])
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+
+		isRunning, err := steamw.IsSteamRunning()
+		if err != nil {
+			logError("Failed to determine if Steam is running - " + err.Error())
+			return nil
 		}
+
+		if !isRunning {
+			return pendingChanges.Apply(steamDataInfo)
+		}
+	}
+
+	logWarn("Steam did not exit in time - " + strconv.Itoa(len(pendingChanges.Changes())) +
+		" shortcut change(s) will be made after Steam exits by the daemon or the next sync")
+
+	return nil
+}
+
+// printStatus prints whether Steam is running and the shortcut changes
+// waiting for it to exit.
+func printStatus(settingsDirPath string) error {
+	isRunning, err := steamw.IsSteamRunning()
+	if err != nil {
+		return errors.New("Failed to determine if Steam is running - " + err.Error())
+	}
+
+	if isRunning {
+		fmt.Println("Steam is running")
+	} else {
+		fmt.Println("Steam is not running")
+	}
+
+	pendingChanges, err := steamw.LoadPendingChanges(settings.PendingChangesFilePath(settingsDirPath))
+	if err != nil {
+		return err
+	}
+
+	if pendingChanges.IsEmpty() {
+		fmt.Println("There are no pending shortcut changes")
+		return nil
+	}
+
+	fmt.Println(len(pendingChanges.Changes()), "pending shortcut change(s):")
+
+	for _, change := range pendingChanges.Changes() {
+		fmt.Println("  " + change.QueuedAt.Format(time.RFC3339) + " - " + change.Description() +
+			" ('" + change.GameDirPath() + "')")
 	}
 
 	return nil
 }
 
+// printKnownGames prints the known games and their shortcut IDs.
+func printKnownGames(settingsDirPath string) error {
+	internalDirPath, err := settings.CreateInternalFilesDir(settingsDirPath)
+	if err != nil {
+		return errors.New("Failed to create internal settings directory path - " + err.Error())
+	}
+
+	knownGames, _ := settings.LoadOrCreateKnownGamesSettings(internalDirPath)
+
+	dirPathsToNames := knownGames.GameDirPathsToGameNames()
+
+	dirPaths := make([]string, 0, len(dirPathsToNames))
+	for dirPath := range dirPathsToNames {
+		dirPaths = append(dirPaths, dirPath)
+	}
+	sort.Strings(dirPaths)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tAPP ID\tLEGACY GRID ID\tDIRECTORY")
+
+	for _, dirPath := range dirPaths {
+		appId, gridId, ok := knownGames.ShortcutIds(dirPath)
+		if !ok {
+			appId = "unknown"
+			gridId = "unknown"
+		}
+
+		fmt.Fprintln(w, dirPathsToNames[dirPath] + "\t" + appId + "\t" + gridId + "\t" + dirPath)
+	}
+
+	return w.Flush()
+}
+
+// printRetroArchCores prints the cores that are installed for
+// a RetroArch launcher, along with the extensions they support.
+func printRetroArchCores(settingsDirPath string, launcherName string) error {
+	launchers := settings.NewLaunchersSettings()
+
+	err := launchers.Reload(path.Join(settingsDirPath, launchers.Filename("")))
+	if err != nil {
+		return errors.New("Failed to load launchers settings - " + err.Error())
+	}
+
+	launcher, hasLauncher := launchers.Has(launcherName)
+	if !hasLauncher {
+		return errors.New("Launcher '" + launcherName + "' does not exist in the launchers configuration file")
+	}
+
+	if launcher.Type() != settings.RetroArchLauncher {
+		return errors.New("Launcher '" + launcherName + "' is not a RetroArch launcher")
+	}
+
+	cores, err := launcher.RetroArchCores()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "NAME\tDISPLAY NAME\tEXTENSIONS")
+
+	for _, core := range cores {
+		fmt.Fprintln(w, core.Name + "\t" + core.DisplayName + "\t" + strings.Join(core.Extensions, " "))
+	}
+
+	return w.Flush()
+}
+
+// syncGameCollections creates or updates shortcuts for every game in every
+// configured game collection. If planOnly is true, the changes are reported
+// but not made. It returns a non-zero exit code if any of the
+// operations failed.
+func syncGameCollections(currentSettings *settingsState, planOnly bool) int {
+	err := currentSettings.load()
+	if err != nil {
+		logError(err.Error())
+		return 1
+	}
+
+	steamDataInfo, err := newSteamDataInfo(currentSettings.app, currentSettings.configDirPath)
+	if err != nil {
+		logError("Failed to get Steam info - " + err.Error())
+		return 1
+	}
+
+	shortcutManager := shortman.NewShortcutManager(shortman.Config{
+		App:              currentSettings.app,
+		KnownGames:       currentSettings.knownGames,
+		Launchers:        currentSettings.launchers,
+		IgnorePathPrefix: currentSettings.configDirPath,
+		PlanOnly:         planOnly,
+		PendingChanges:   currentSettings.pendingChanges,
+		Metadata:         currentSettings.metadata,
+	})
+
+	operationName := "Sync"
+	if planOnly {
+		operationName = "Plan"
+	}
+
+	var numFailed int
+
+	syncResults := shortcutManager.UpdateAll(steamDataInfo)
+
+	if !planOnly {
+		syncResults = append(syncResults, waitForSteamExit(currentSettings, steamDataInfo)...)
+	}
+
+	for _, r := range syncResults {
+		if r.Outcome() == results.Failed {
+			numFailed++
+		}
+	}
+
+	logResults(syncResults)
+
+	if numFailed > 0 {
+		logError(operationName, "finished with", numFailed, "failure(s)")
+		return 1
+	}
+
+	logInfo(operationName, "finished")
+
+	return 0
+}
+
 func mainLoop(currentSettings *settingsState, stop chan chan struct{}) {
 	currentSettings.watcher.Start()
 
 	gameCollectionChanges := make(chan watcher.Change)
-	dirPathsToWatchers  := make(map[string]watcher.Watcher)
+	dirPathsToWatchers  := make(map[string]*collectionWatcher)
 
 	shortcutManager := shortman.NewShortcutManager(shortman.Config{
 		App:              currentSettings.app,
 		KnownGames:       currentSettings.knownGames,
 		Launchers:        currentSettings.launchers,
 		IgnorePathPrefix: currentSettings.configDirPath,
+		PendingChanges:   currentSettings.pendingChanges,
+		Metadata:         currentSettings.metadata,
 	})
 
 	updateCollectionsTimer := newStoppedTimer()
 
+	pendingChangesTicker := time.NewTicker(10 * time.Second)
+	defer pendingChangesTicker.Stop()
+
+	steamShutdownRequested := false
+
 	refreshKnownGamesTimer := newStoppedTimer()
 
 	timerDuration := 5 * time.Second
@@ -355,18 +791,53 @@ func mainLoop(currentSettings *settingsState, stop chan chan struct{}) {
 			logInfo("Updating game collections...")
 
 			updateGameCollectionWatchers(currentSettings, dirPathsToWatchers, gameCollectionChanges)
+		case <-pendingChangesTicker.C:
+			if currentSettings.pendingChanges.IsEmpty() {
+				continue
+			}
+
+			isRunning, err := steamw.IsSteamRunning()
+			if err != nil {
+				logError("Failed to determine if Steam is running - " + err.Error())
+				continue
+			}
+
+			if isRunning {
+				if currentSettings.app.CloseSteamForChanges() && !steamShutdownRequested {
+					logInfo("Asking Steam to exit so that pending shortcut changes can be made...")
+
+					err := steamw.RequestSteamShutdown()
+					if err != nil {
+						logError(err.Error())
+					}
+
+					steamShutdownRequested = true
+				}
+
+				continue
+			}
+
+			steamShutdownRequested = false
+
+			logInfo("Steam has exited, making pending shortcut changes...")
+
+			steamDataInfo, err := newSteamDataInfo(currentSettings.app, currentSettings.configDirPath)
+			if err != nil {
+				logError("Failed to get Steam info - " + err.Error())
+				continue
+			}
+
+			logResults(currentSettings.pendingChanges.Apply(steamDataInfo))
 		case <-refreshKnownGamesTimer.C:
 			logInfo("Refreshing known games and their shortcuts...")
 
-			steamDataInfo, err := steamw.NewSteamDataInfo()
+			steamDataInfo, err := newSteamDataInfo(currentSettings.app, currentSettings.configDirPath)
 			if err != nil {
 				logError("Failed to get Steam info - " + err.Error())
 				continue
 			}
 
-			for _, r := range shortcutManager.RefreshAll(steamDataInfo) {
-				logResult(r)
-			}
+			logResults(shortcutManager.RefreshAll(steamDataInfo))
 		case collectionChange := <-gameCollectionChanges:
 			if collectionChange.IsErr() {
 				logError("Failed to get changes for game collection - " + collectionChange.ErrDetails())
@@ -375,7 +846,7 @@ func mainLoop(currentSettings *settingsState, stop chan chan struct{}) {
 				continue
 			}
 
-			steamDataInfo, err := steamw.NewSteamDataInfo()
+			steamDataInfo, err := newSteamDataInfo(currentSettings.app, currentSettings.configDirPath)
 			if err != nil {
 				logError("Failed to get Steam info - " + err.Error())
 				continue
@@ -400,9 +871,7 @@ func mainLoop(currentSettings *settingsState, stop chan chan struct{}) {
 				collectionChange.DeletedFilePathsWithoutSuffixes(settings.GameImageSuffixes),
 				false, steamDataInfo)...)
 
-			for _, r := range res {
-				logResult(r)
-			}
+			logResults(res)
 		case rejoin := <-stop:
 			for k, w := range dirPathsToWatchers {
 				w.Destroy()
@@ -434,7 +903,14 @@ func stopTimerSafely(t *time.Timer) {
 	}
 }
 
-func updateGameCollectionWatchers(currentSettings *settingsState, dirPathsToWatchers map[string]watcher.Watcher, changes chan watcher.Change) {
+// collectionWatcher watches a game collection for changes.
+type collectionWatcher struct {
+	watcher.Watcher
+	layout settings.CollectionLayout
+	depth  int
+}
+
+func updateGameCollectionWatchers(currentSettings *settingsState, dirPathsToWatchers map[string]*collectionWatcher, changes chan watcher.Change) {
 	gameCollectionsToLauncherNames := currentSettings.app.GameCollectionsPathsToLauncherNames()
 
 	// Stop watchers for game collection directories we are no longer watching.
@@ -451,15 +927,26 @@ func updateGameCollectionWatchers(currentSettings *settingsState, dirPathsToWatc
 		delete(dirPathsToWatchers, dirPath)
 	}
 
+	// Launchers are shared by the collections that use them, so that
+	// each launcher only discovers its RetroArch cores once.
+	launchers := make(map[string]settings.Launcher)
+
 	// Create and start new game collection watchers.
 	for collectionDirPath, launcherName := range gameCollectionsToLauncherNames {
-		launcher, hasLauncher := currentSettings.launchers.Has(launcherName)
+		launcher, hasLauncher := launchers[launcherName]
 		if !hasLauncher {
-			logError("The collection '" + collectionDirPath + "' will not be added - Launcher '" +
-				launcher.Name() + "' does not exist in the launchers configuration file")
-			continue
+			launcher, hasLauncher = currentSettings.launchers.Has(launcherName)
+			if !hasLauncher {
+				logError("The collection '" + collectionDirPath + "' will not be added - Launcher '" +
+					launcher.Name() + "' does not exist in the launchers configuration file")
+				continue
+			}
+
+			launchers[launcherName] = launcher
 		}
 
+		launcher = launcher.WithRetroArchCore(currentSettings.app.GameCollectionRetroArchCore(collectionDirPath))
+
 		err := launcher.IsValid()
 		if err != nil {
 			logError("The collection '" + collectionDirPath +
@@ -467,19 +954,34 @@ func updateGameCollectionWatchers(currentSettings *settingsState, dirPathsToWatc
 			continue
 		}
 
-		w, hasWatcher := dirPathsToWatchers[collectionDirPath]
-		if hasWatcher && areSlicesEqual(w.Config().ScanCriteria, launcher.GameFileSuffixes()) {
+		layout := currentSettings.app.GameCollectionLayout(collectionDirPath)
+		depth := currentSettings.app.GameCollectionDepth(collectionDirPath)
+		scanCriteria := gameFileScanCriteria(launcher)
+
+		existing, hasWatcher := dirPathsToWatchers[collectionDirPath]
+		if hasWatcher {
+			if existing.layout == layout && existing.depth == depth && areSlicesEqual(existing.Config().ScanCriteria, scanCriteria) {
+				continue
+			}
+
+			existing.Stop()
+		}
+
+		matcher, err := launcher.GameFileMatcher()
+		if err != nil {
+			logError("The collection '" + collectionDirPath +
+				"' will not be added - The launcher is invalid - " + err.Error())
 			continue
 		}
 
 		collectionWatcherConfig := watcher.Config{
-			ScanFunc:     watcher.ScanFilesInSubdirectories,
+			ScanFunc:     gameFileScanFunc(layout, depth, matcher),
 			RootDirPath:  collectionDirPath,
-			ScanCriteria: append(launcher.GameFileSuffixes(), settings.GameImageSuffixes...),
+			ScanCriteria: scanCriteria,
 			Changes:      changes,
 		}
 
-		w, err = watcher.NewWatcher(collectionWatcherConfig)
+		w, err := watcher.NewWatcher(collectionWatcherConfig)
 		if err != nil {
 			logError("Failed to create game collection watcher for " +
 				collectionDirPath + " - " + err.Error())
@@ -490,8 +992,106 @@ func updateGameCollectionWatchers(currentSettings *settingsState, dirPathsToWatc
 
 		w.Start()
 
-		dirPathsToWatchers[collectionDirPath] = w
+		dirPathsToWatchers[collectionDirPath] = &collectionWatcher{
+			Watcher: w,
+			layout:  layout,
+			depth:   depth,
+		}
+	}
+}
+
+// gameFileScanCriteria returns the launcher's game file patterns,
+// suffixes, and excludes (prefixed with '!'), followed by the game
+// image suffixes. The criteria are used to tell when a game
+// collection's watcher needs to be replaced.
+func gameFileScanCriteria(launcher settings.Launcher) []string {
+	var criteria []string
+
+	criteria = append(criteria, launcher.GameFilePatterns()...)
+	criteria = append(criteria, launcher.GameFileSuffixes()...)
+
+	for _, exclude := range launcher.GameFileExcludes() {
+		criteria = append(criteria, "!" + exclude)
+	}
+
+	return append(criteria, settings.GameImageSuffixes...)
+}
+
+// gameFileScanFunc returns a watcher.ScanFunc that finds the game files
+// and game images in a game collection. Games may be inside of grouping
+// directories, up to the specified depth. Games in a flat game
+// collection are files rather than directories.
+func gameFileScanFunc(layout settings.CollectionLayout, depth int, matcher settings.FileMatcher) func(watcher.Config) (watcher.ScanResult, error) {
+	// Game files in a flat collection are one level higher than
+	// the files in game directories.
+	maxLevel := depth + 1
+	if layout == settings.FlatLayout {
+		maxLevel = depth
+	}
+
+	return func(config watcher.Config) (watcher.ScanResult, error) {
+		result := watcher.ScanResult{
+			FilePathsToInfo: make(map[string]watcher.MatchInfo),
+		}
+
+		err := scanDirForGameFiles(config.RootDirPath, 0, maxLevel, layout, matcher, result)
+		if err != nil {
+			return watcher.ScanResult{}, err
+		}
+
+		return result, nil
+	}
+}
+
+// scanDirForGameFiles adds the game images and game files in the
+// directory to the result, and then scans its subdirectories until
+// maxLevel is reached. Files in the game collection itself are only
+// added for flat game collections. Game images are matched on their
+// suffix so that they can be told apart from game files.
+func scanDirForGameFiles(dirPath string, level int, maxLevel int, layout settings.CollectionLayout, matcher settings.FileMatcher, result watcher.ScanResult) error {
+	infos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		filePath := path.Join(dirPath, info.Name())
+
+		if info.IsDir() {
+			if level < maxLevel {
+				err := scanDirForGameFiles(filePath, level + 1, maxLevel, layout, matcher, result)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if level == 0 && layout != settings.FlatLayout {
+			continue
+		}
+
+		matchedOn, isImage := imageSuffix(info.Name())
+		if _, isGameFile := matcher.Match(info.Name()); isImage || isGameFile {
+			result.FilePathsToInfo[filePath] = watcher.MatchInfo{
+				Path:      filePath,
+				MatchedOn: matchedOn,
+				ModTime:   info.ModTime(),
+			}
+		}
+	}
+
+	return nil
+}
+
+func imageSuffix(filename string) (string, bool) {
+	for _, suffix := range settings.GameImageSuffixes {
+		if strings.HasSuffix(filename, suffix) {
+			return suffix, true
+		}
 	}
+
+	return "", false
 }
 
 func areSlicesEqual(a[]string , b []string) bool {
@@ -512,14 +1112,31 @@ func areSlicesEqual(a[]string , b []string) bool {
 	return true
 }
 
+func logResults(r []results.Result) {
+	for i := range r {
+		logResult(r[i])
+	}
+
+	if resultsOutput == nil || len(r) == 0 {
+		return
+	}
+
+	err := results.Write(r, resultsFormat, resultsOutput)
+	if err != nil {
+		logError("Failed to write results -", err.Error())
+	}
+}
+
 func logResult(result results.Result) {
 	switch result.Outcome() {
-	case results.SucceededWithWarning:
+	case results.SucceededWithWarning, results.Duplicate:
 		logWarn(result.PrintableResult())
 	case results.Failed:
 		logError(result.PrintableResult())
 	case results.Succeeded:
 		fallthrough
+	case results.Planned:
+		fallthrough
 	case results.Skipped:
 		fallthrough
 	default:
@@ -1,5 +1,7 @@
 module github.com/stephen-fox/grundy
 
+go 1.18
+
 require (
 	github.com/go-ini/ini v1.39.3
 	github.com/gopherjs/gopherjs v0.0.0-20181103185306-d547d1d9531e // indirect
@@ -12,5 +14,7 @@ require (
 	github.com/stephen-fox/launchctlutil v1.1.0
 	github.com/stephen-fox/steamutil v1.3.0
 	github.com/stephen-fox/watcher v0.1.0
+	golang.org/x/image v0.18.0
+	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a // indirect
 	gopkg.in/ini.v1 v1.39.3 // indirect
 )
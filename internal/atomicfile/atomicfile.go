@@ -0,0 +1,39 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// WriteFile writes the data to a temporary file in the same directory as
+// the destination file, and then renames it to the destination. Other
+// programs, such as Steam, never see a partially written file this way.
+func WriteFile(filePath string, data []byte, mode os.FileMode) error {
+	temp, err := ioutil.TempFile(path.Dir(filePath), "." + path.Base(filePath) + "-")
+	if err != nil {
+		return err
+	}
+	tempFilePath := temp.Name()
+
+	_, err = temp.Write(data)
+	if err == nil {
+		err = temp.Sync()
+	}
+	closeErr := temp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFilePath, mode)
+	}
+	if err == nil {
+		err = os.Rename(tempFilePath, filePath)
+	}
+	if err != nil {
+		os.Remove(tempFilePath)
+		return err
+	}
+
+	return nil
+}
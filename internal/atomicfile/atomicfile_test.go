@@ -0,0 +1,52 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-atomicfile-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	filePath := path.Join(dirPath, "file.ini")
+
+	for _, data := range []string{"a longer first version", "second"} {
+		err := WriteFile(filePath, []byte(data), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		written, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if string(written) != data {
+			t.Fatal("Expected file to contain '" + data + "' - got '" + string(written) + "'")
+		}
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Fatal("Unexpected file mode -", info.Mode().Perm())
+	}
+
+	infos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(infos) != 1 {
+		t.Fatal("Temporary files should not be left behind - found", len(infos), "files")
+	}
+}
@@ -0,0 +1,3 @@
+// Package atomicfile writes files so that readers never see them
+// partially written.
+package atomicfile
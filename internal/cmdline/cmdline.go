@@ -0,0 +1,186 @@
+package cmdline
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	windows = "windows"
+
+	// posixSafeCharacters are the characters that do not need to be
+	// quoted in a POSIX shell.
+	posixSafeCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./-_"
+
+	// windowsUnsafeCharacters are the characters that cause a Windows
+	// argument to be quoted. Besides whitespace and double quotes, this
+	// includes the characters that mean something to cmd.exe, in case
+	// the arguments end up being interpreted by it.
+	windowsUnsafeCharacters = " \t\n\v\"&|<>^%()"
+)
+
+// Split splits a string into arguments using shell-like rules. Arguments
+// are separated by whitespace. Text inside of single quotes is literal.
+// Inside of double quotes, a backslash only escapes a double quote.
+// Outside of quotes, a backslash only escapes whitespace and quotes.
+// All other backslashes are literal, so that Windows paths do not need
+// to be escaped. Split is the inverse of Format.
+func Split(s string) ([]string, error) {
+	var args []string
+	var current []rune
+	inArg := false
+
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		switch {
+		case c == '\'':
+			inArg = true
+			end := indexRune(runes, i + 1, '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote in '" + s + "'")
+			}
+
+			current = append(current, runes[i + 1:end]...)
+			i = end
+		case c == '"':
+			inArg = true
+			closed := false
+
+			for i = i + 1; i < len(runes); i++ {
+				if runes[i] == '\\' && i + 1 < len(runes) && runes[i + 1] == '"' {
+					current = append(current, runes[i + 1])
+					i++
+					continue
+				}
+
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+
+				current = append(current, runes[i])
+			}
+
+			if !closed {
+				return nil, errors.New("unterminated double quote in '" + s + "'")
+			}
+		case c == '\\' && i + 1 < len(runes) && isEscapable(runes[i + 1]):
+			inArg = true
+			current = append(current, runes[i + 1])
+			i++
+		case isSpace(c):
+			if inArg {
+				args = append(args, string(current))
+				current = nil
+				inArg = false
+			}
+		default:
+			inArg = true
+			current = append(current, c)
+		}
+	}
+
+	if inArg {
+		args = append(args, string(current))
+	}
+
+	return args, nil
+}
+
+func indexRune(runes []rune, start int, r rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\v'
+}
+
+func isEscapable(r rune) bool {
+	return isSpace(r) || r == '"' || r == '\''
+}
+
+// Format joins the arguments into a string that Split parses back into
+// the same arguments.
+func Format(args []string) string {
+	return Join(args, "")
+}
+
+// Join joins the arguments into a command line for the operating system,
+// which is a runtime.GOOS value. The arguments are quoted for Windows'
+// CommandLineToArgvW function on Windows, and for a POSIX shell on
+// other operating systems.
+func Join(args []string, goos string) string {
+	quoted := make([]string, len(args))
+
+	for i := range args {
+		quoted[i] = Quote(args[i], goos)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// Quote quotes the argument if it needs to be quoted on the operating
+// system, which is a runtime.GOOS value.
+func Quote(arg string, goos string) string {
+	if goos == windows {
+		return quoteWindows(arg)
+	}
+
+	return quotePosix(arg)
+}
+
+// quoteWindows quotes an argument so that CommandLineToArgvW parses it
+// back into the same argument. Backslashes are only special when they
+// precede a double quote, in which case they must be doubled.
+func quoteWindows(arg string) string {
+	if len(arg) > 0 && !strings.ContainsAny(arg, windowsUnsafeCharacters) {
+		return arg
+	}
+
+	var b strings.Builder
+
+	b.WriteByte('"')
+
+	backslashes := 0
+
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			backslashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat("\\", backslashes * 2 + 1))
+		default:
+			b.WriteString(strings.Repeat("\\", backslashes))
+		}
+
+		backslashes = 0
+		b.WriteRune(r)
+	}
+
+	// Backslashes before the closing quote must be escaped so that
+	// they do not escape the quote.
+	b.WriteString(strings.Repeat("\\", backslashes * 2))
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// quotePosix quotes an argument with single quotes if it contains
+// characters that a POSIX shell would interpret.
+func quotePosix(arg string) string {
+	if len(arg) > 0 && strings.Trim(arg, posixSafeCharacters) == "" {
+		return arg
+	}
+
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
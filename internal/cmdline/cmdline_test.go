@@ -0,0 +1,174 @@
+package cmdline
+
+import (
+	"reflect"
+	"testing"
+)
+
+var roundTripArgs = [][]string{
+	{},
+	{"--batch"},
+	{"-e", "/games/Metroid Prime/Metroid Prime.iso"},
+	{"C:\\Games\\Half-Life\\hl.exe", "-game", "cstrike"},
+	{"C:\\Program Files\\Emulator\\", "trailing backslash"},
+	{"\\\\server\\share\\game.iso"},
+	{"say \"hello\"", "it's", "\\\"", "\\\\\""},
+	{"100%", "a&b", "a|b", "<in>", "(group)", "^caret", "$HOME", "`cmd`", "!bang"},
+	{"", "empty", ""},
+	{"tab\tseparated", "new\nline"},
+	{"ünïcödé", "日本語 ゲーム"},
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		s    string
+		args []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"--batch -e", []string{"--batch", "-e"}},
+		{"  a   b\tc  ", []string{"a", "b", "c"}},
+		{"-e \"C:\\Games\\My Game\\game.iso\"", []string{"-e", "C:\\Games\\My Game\\game.iso"}},
+		{"C:\\Games\\game.iso", []string{"C:\\Games\\game.iso"}},
+		{"\\\\server\\share", []string{"\\\\server\\share"}},
+		{"'C:\\Games\\'", []string{"C:\\Games\\"}},
+		{"'it'\\''s'", []string{"it's"}},
+		{"\"say \\\"hi\\\"\"", []string{"say \"hi\""}},
+		{"my\\ game", []string{"my game"}},
+		{"--file=\"a b\"c", []string{"--file=a bc"}},
+		{"\"\" ''", []string{"", ""}},
+	}
+
+	for _, test := range tests {
+		args, err := Split(test.s)
+		if err != nil {
+			t.Fatal("failed to split '" + test.s + "' - " + err.Error())
+		}
+
+		if !reflect.DeepEqual(args, test.args) {
+			t.Fatalf("split of '%s' is %q - expected %q", test.s, args, test.args)
+		}
+	}
+}
+
+func TestSplitUnterminatedQuote(t *testing.T) {
+	for _, s := range []string{"'abc", "\"abc", "a \"b\\\""} {
+		_, err := Split(s)
+		if err == nil {
+			t.Fatal("expected an error when splitting '" + s + "'")
+		}
+	}
+}
+
+func TestFormatSplitRoundTrip(t *testing.T) {
+	for _, args := range roundTripArgs {
+		s := Format(args)
+
+		split, err := Split(s)
+		if err != nil {
+			t.Fatal("failed to split '" + s + "' - " + err.Error())
+		}
+
+		if len(args) == 0 && len(split) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(split, args) {
+			t.Fatalf("'%s' was split into %q - expected %q", s, split, args)
+		}
+	}
+}
+
+func TestJoinWindowsRoundTrip(t *testing.T) {
+	for _, args := range roundTripArgs {
+		s := Join(args, "windows")
+
+		parsed := commandLineToArgv(s)
+
+		if len(args) == 0 && len(parsed) == 0 {
+			continue
+		}
+
+		if !reflect.DeepEqual(parsed, args) {
+			t.Fatalf("'%s' was parsed into %q - expected %q", s, parsed, args)
+		}
+	}
+}
+
+func TestJoinWindowsDoesNotQuoteSimpleArgs(t *testing.T) {
+	s := Join([]string{"-b", "-e", "C:\\Games\\game.iso"}, "windows")
+
+	if s != "-b -e C:\\Games\\game.iso" {
+		t.Fatal("unexpected command line - '" + s + "'")
+	}
+}
+
+func TestJoinPosix(t *testing.T) {
+	s := Join([]string{"-e", "/games/My Game/it's.iso", "--fullscreen"}, "linux")
+
+	expected := "-e '/games/My Game/it'\\''s.iso' --fullscreen"
+
+	if s != expected {
+		t.Fatal("command line is '" + s + "' - expected '" + expected + "'")
+	}
+}
+
+// commandLineToArgv parses a command line the same way as Windows'
+// CommandLineToArgvW function does for arguments after the program name.
+func commandLineToArgv(s string) []string {
+	var args []string
+	var current []rune
+	inArg := false
+	inQuotes := false
+	backslashes := 0
+
+	for _, r := range s {
+		if r == '\\' {
+			backslashes++
+			inArg = true
+			continue
+		}
+
+		if r == '"' {
+			for i := 0; i < backslashes / 2; i++ {
+				current = append(current, '\\')
+			}
+
+			if backslashes % 2 == 1 {
+				current = append(current, '"')
+			} else {
+				inQuotes = !inQuotes
+			}
+
+			backslashes = 0
+			inArg = true
+			continue
+		}
+
+		for ; backslashes > 0; backslashes-- {
+			current = append(current, '\\')
+		}
+
+		if (r == ' ' || r == '\t') && !inQuotes {
+			if inArg {
+				args = append(args, string(current))
+				current = nil
+				inArg = false
+			}
+			continue
+		}
+
+		current = append(current, r)
+		inArg = true
+	}
+
+	for ; backslashes > 0; backslashes-- {
+		current = append(current, '\\')
+	}
+
+	if inArg {
+		args = append(args, string(current))
+	}
+
+	return args
+}
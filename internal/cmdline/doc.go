@@ -0,0 +1,3 @@
+// Package cmdline splits command line arguments from strings, and joins
+// them back together with the quoting rules of an operating system.
+package cmdline
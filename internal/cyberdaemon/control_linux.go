@@ -0,0 +1,209 @@
+package cyberdaemon
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"strings"
+	"syscall"
+)
+
+const (
+	systemdUnitFileMode    = 0644
+	systemdUnitDirMode     = 0755
+	systemdUnitSuffix      = ".service"
+	systemdUserUnitSubPath = ".config/systemd/user"
+)
+
+type linuxDaemon struct {
+	unitName     string
+	unitFilePath string
+	unitContents string
+}
+
+func (o *linuxDaemon) Status() (Status, error) {
+	_, statErr := os.Stat(o.unitFilePath)
+	if statErr != nil {
+		return NotInstalled, nil
+	}
+
+	// 'is-active' exits non-zero when the unit is not active, so the
+	// error is ignored in favor of the printed state.
+	output, _ := systemctl("is-active", o.unitName)
+
+	switch strings.TrimSpace(output) {
+	case "active", "reloading", "activating", "deactivating":
+		return Running, nil
+	case "inactive", "failed":
+		return Stopped, nil
+	}
+
+	return Unknown, nil
+}
+
+func (o *linuxDaemon) ExecuteCommand(command Command) (string, error) {
+	if command == GetStatus {
+		status, err := o.Status()
+		if err != nil {
+			return "", err
+		}
+
+		return status.printableStatus(), nil
+	}
+
+	switch command {
+	case Start:
+		_, err := systemctl("start", o.unitName)
+		if err != nil {
+			return "", err
+		}
+
+		return executedCommandMessage(command), nil
+	case Stop:
+		_, err := systemctl("stop", o.unitName)
+		if err != nil {
+			return "", err
+		}
+
+		return executedCommandMessage(command), nil
+	case Install:
+		err := os.MkdirAll(path.Dir(o.unitFilePath), systemdUnitDirMode)
+		if err != nil {
+			return "", err
+		}
+
+		err = ioutil.WriteFile(o.unitFilePath, []byte(o.unitContents), systemdUnitFileMode)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = systemctl("daemon-reload")
+		if err != nil {
+			return "", err
+		}
+
+		_, err = systemctl("enable", o.unitName)
+		if err != nil {
+			return "", err
+		}
+
+		return executedCommandMessage(command), nil
+	case Uninstall:
+		// The unit may already be stopped or disabled, which is fine.
+		systemctl("stop", o.unitName)
+		systemctl("disable", o.unitName)
+
+		err := os.Remove(o.unitFilePath)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+
+		_, err = systemctl("daemon-reload")
+		if err != nil {
+			return "", err
+		}
+
+		return executedCommandMessage(command), nil
+	}
+
+	return "", CommandError{
+		isUnknown: true,
+		command:   command,
+	}
+}
+
+func (o *linuxDaemon) BlockAndRun(logic ApplicationLogic) error {
+	c := make(chan os.Signal, 1)
+
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(c)
+
+	err := logic.Start()
+	if err != nil {
+		return err
+	}
+
+	<-c
+
+	err = logic.Stop()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func NewDaemon(config Config) (Daemon, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return &linuxDaemon{}, err
+	}
+
+	homePath := os.Getenv("HOME")
+	if len(strings.TrimSpace(homePath)) == 0 {
+		return &linuxDaemon{}, errors.New("The HOME environment variable is not set")
+	}
+
+	unitName := config.Name + systemdUnitSuffix
+
+	return &linuxDaemon{
+		unitName:     unitName,
+		unitFilePath: path.Join(homePath, systemdUserUnitSubPath, unitName),
+		unitContents: systemdUnitContents(config, exePath),
+	}, nil
+}
+
+func systemdUnitContents(config Config, exePath string) string {
+	buffer := bytes.NewBuffer(nil)
+
+	buffer.WriteString("[Unit]\n")
+	buffer.WriteString("Description=")
+	buffer.WriteString(config.Description)
+	buffer.WriteString("\n\n")
+
+	buffer.WriteString("[Service]\n")
+	buffer.WriteString("ExecStart=")
+	buffer.WriteString(systemdQuote(exePath))
+	buffer.WriteString("\n")
+	buffer.WriteString("Restart=on-failure\n\n")
+
+	buffer.WriteString("[Install]\n")
+	buffer.WriteString("WantedBy=default.target\n")
+
+	return buffer.String()
+}
+
+// systemdQuote quotes a word of a systemd command line. Percent signs
+// and dollar signs are doubled so that systemd does not expand them as
+// specifiers or environment variables.
+func systemdQuote(s string) string {
+	s = strings.Replace(s, "%", "%%", -1)
+	s = strings.Replace(s, "$", "$$", -1)
+
+	if !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "\"", "\\\"", -1)
+
+	return "\"" + s + "\""
+}
+
+func systemctl(args ...string) (string, error) {
+	args = append([]string{"--user"}, args...)
+
+	output, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return string(output), CommandError{
+			reason: "Failed to execute 'systemctl " + strings.Join(args, " ") +
+				"' - " + err.Error() + " - " + strings.TrimSpace(string(output)),
+		}
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,27 @@
+package cyberdaemon
+
+import (
+	"testing"
+)
+
+func TestSystemdQuote(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"/usr/bin/grundy", "/usr/bin/grundy"},
+		{"/opt/My Apps/grundy", `"/opt/My Apps/grundy"`},
+		{`/opt/"quoted"/grundy`, `"/opt/\"quoted\"/grundy"`},
+		{`/opt/back\slash/grundy`, `"/opt/back\\slash/grundy"`},
+		{"/opt/100%/grundy", "/opt/100%%/grundy"},
+		{"/opt/$HOME/grundy", "/opt/$$HOME/grundy"},
+		{"/opt/100% done/grundy", `"/opt/100%% done/grundy"`},
+	}
+
+	for _, test := range tests {
+		quoted := systemdQuote(test.value)
+		if quoted != test.expected {
+			t.Errorf("'%s' was quoted as '%s' - expected '%s'", test.value, quoted, test.expected)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package metadata
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DatFileExtension = ".dat"
+)
+
+var (
+	// tagsPattern matches the parenthesized and bracketed tags that
+	// follow a title in a DAT file, such as '(USA) (Rev 1)'.
+	tagsPattern = regexp.MustCompile(`\s*[(\[]([^)\]]*)[)\]]`)
+
+	// articlePattern matches titles whose leading article was moved to
+	// the end for sorting, such as 'Legend of Zelda, The - The Wind Waker'.
+	articlePattern = regexp.MustCompile(`^(.+?), (The|A|An)( - .+)?$`)
+
+	regions = map[string]bool{
+		"World":       true,
+		"USA":         true,
+		"Europe":      true,
+		"Japan":       true,
+		"Asia":        true,
+		"Australia":   true,
+		"Brazil":      true,
+		"Canada":      true,
+		"China":       true,
+		"France":      true,
+		"Germany":     true,
+		"Hong Kong":   true,
+		"Italy":       true,
+		"Korea":       true,
+		"Netherlands": true,
+		"Russia":      true,
+		"Scandinavia": true,
+		"Spain":       true,
+		"Sweden":      true,
+		"Taiwan":      true,
+		"UK":          true,
+	}
+)
+
+// DatProvider looks up games in DAT files, which are the XML game
+// databases published by preservation projects such as No-Intro and
+// Redump. Games are matched by the name of their primary file, or by
+// its size and hash.
+type DatProvider interface {
+	Provider
+
+	// Reload reloads the DAT files from the provider's directory.
+	Reload() error
+}
+
+type datFile struct {
+	Header struct {
+		Name string `xml:"name"`
+	} `xml:"header"`
+	Games    []datGame `xml:"game"`
+	Machines []datGame `xml:"machine"`
+}
+
+type datGame struct {
+	Name        string   `xml:"name,attr"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+	Roms        []datRom `xml:"rom"`
+}
+
+type datRom struct {
+	Name string `xml:"name,attr"`
+	Size int64  `xml:"size,attr"`
+	CRC  string `xml:"crc,attr"`
+	SHA1 string `xml:"sha1,attr"`
+}
+
+// datEntry is a ROM from a DAT file along with the game it belongs to.
+type datEntry struct {
+	rom  datRom
+	game Game
+}
+
+type defaultDatProvider struct {
+	dirPath      string
+	mutex        *sync.RWMutex
+	namesToGames map[string]Game
+	sizesToRoms  map[int64][]datEntry
+}
+
+func (o *defaultDatProvider) Name() string {
+	return "DAT files in '" + o.dirPath + "'"
+}
+
+func (o *defaultDatProvider) Reload() error {
+	namesToGames := make(map[string]Game)
+	sizesToRoms := make(map[int64][]datEntry)
+
+	infos, err := ioutil.ReadDir(o.dirPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var errs []string
+
+	for _, fileInfo := range infos {
+		if fileInfo.IsDir() || !strings.EqualFold(path.Ext(fileInfo.Name()), DatFileExtension) {
+			continue
+		}
+
+		err := loadDatFile(path.Join(o.dirPath, fileInfo.Name()), namesToGames, sizesToRoms)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	o.mutex.Lock()
+	o.namesToGames = namesToGames
+	o.sizesToRoms = sizesToRoms
+	o.mutex.Unlock()
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+func loadDatFile(filePath string, namesToGames map[string]Game, sizesToRoms map[int64][]datEntry) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var dat datFile
+
+	err = xml.NewDecoder(f).Decode(&dat)
+	if err != nil {
+		return errors.New("failed to parse DAT file '" + filePath + "' - " + err.Error())
+	}
+
+	for _, g := range append(dat.Games, dat.Machines...) {
+		game := parseDatGame(g, dat.Header.Name)
+		game.Source = path.Base(filePath)
+
+		for _, rom := range g.Roms {
+			namesToGames[nameKey(rom.Name)] = game
+			namesToGames[nameKey(trimExtension(rom.Name))] = game
+
+			if rom.Size > 0 && (len(rom.SHA1) > 0 || len(rom.CRC) > 0) {
+				sizesToRoms[rom.Size] = append(sizesToRoms[rom.Size], datEntry{
+					rom:  rom,
+					game: game,
+				})
+			}
+		}
+
+		if _, exists := namesToGames[nameKey(g.Name)]; !exists {
+			namesToGames[nameKey(g.Name)] = game
+		}
+	}
+
+	return nil
+}
+
+// parseDatGame converts a game from a DAT file. The game's title is its
+// name without tags, and its region tags become its regions. The DAT's
+// name, which is normally the name of the platform, is used as
+// a category.
+func parseDatGame(g datGame, datName string) Game {
+	game := Game{
+		Title: strings.TrimSpace(tagsPattern.ReplaceAllString(g.Name, "")),
+	}
+
+	if matches := articlePattern.FindStringSubmatch(game.Title); matches != nil {
+		game.Title = matches[2] + " " + matches[1] + matches[3]
+	}
+
+	if len(datName) > 0 {
+		game.Categories = append(game.Categories, datName)
+	}
+
+	for _, c := range g.Categories {
+		c = strings.TrimSpace(c)
+		// Nearly every entry in a Redump DAT is in the 'Games'
+		// category, which is not worth a Steam category.
+		if len(c) > 0 && c != "Games" {
+			game.Categories = append(game.Categories, c)
+		}
+	}
+
+	for _, tags := range tagsPattern.FindAllStringSubmatch(g.Name, -1) {
+		for _, tag := range strings.Split(tags[1], ",") {
+			tag = strings.TrimSpace(tag)
+			if regions[tag] {
+				game.Regions = append(game.Regions, tag)
+			}
+		}
+	}
+
+	return game
+}
+
+func (o *defaultDatProvider) Lookup(query Query) (Game, bool, error) {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+
+	filename := filepath.Base(query.FilePath)
+
+	game, found := o.namesToGames[nameKey(filename)]
+	if found {
+		return game, true, nil
+	}
+
+	game, found = o.namesToGames[nameKey(trimExtension(filename))]
+	if found {
+		return game, true, nil
+	}
+
+	info, err := os.Stat(query.FilePath)
+	if err != nil {
+		return Game{}, false, err
+	}
+
+	candidates := o.sizesToRoms[info.Size()]
+	if len(candidates) == 0 {
+		return Game{}, false, nil
+	}
+
+	if len(query.CRC32) == 0 || len(query.SHA1) == 0 {
+		query.CRC32, query.SHA1, err = FileHashes(query.FilePath)
+		if err != nil {
+			return Game{}, false, err
+		}
+	}
+
+	for _, c := range candidates {
+		if (len(c.rom.SHA1) > 0 && strings.EqualFold(c.rom.SHA1, query.SHA1)) ||
+			(len(c.rom.SHA1) == 0 && strings.EqualFold(c.rom.CRC, query.CRC32)) {
+			return c.game, true, nil
+		}
+	}
+
+	return Game{}, false, nil
+}
+
+// FileHashes returns the hex encoded CRC32 and SHA1 of a file.
+func FileHashes(filePath string) (string, string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+
+	crcHash := crc32.NewIEEE()
+	sha1Hash := sha1.New()
+
+	_, err = io.Copy(io.MultiWriter(crcHash, sha1Hash), f)
+	if err != nil {
+		return "", "", err
+	}
+
+	crc := strconv.FormatUint(uint64(crcHash.Sum32()), 16)
+	crc = strings.Repeat("0", 8 - len(crc)) + crc
+
+	return crc, hex.EncodeToString(sha1Hash.Sum(nil)), nil
+}
+
+func nameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func trimExtension(filename string) string {
+	return strings.TrimSuffix(filename, path.Ext(filename))
+}
+
+// NewDatProvider returns a DatProvider for the DAT files in a directory.
+// The DAT files are not loaded until Reload is called.
+func NewDatProvider(dirPath string) DatProvider {
+	return &defaultDatProvider{
+		dirPath:      dirPath,
+		mutex:        &sync.RWMutex{},
+		namesToGames: make(map[string]Game),
+		sizesToRoms:  make(map[int64][]datEntry),
+	}
+}
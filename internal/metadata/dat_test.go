@@ -0,0 +1,91 @@
+package metadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDatProviderLookup(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-dat-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	renamedFilePath := path.Join(dirPath, "my copy.iso")
+	err = ioutil.WriteFile(renamedFilePath, []byte("wind waker"), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	crc, sha, err := FileHashes(renamedFilePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dat := `<?xml version="1.0"?>
+<datafile>
+	<header>
+		<name>Nintendo - GameCube</name>
+	</header>
+	<game name="Metroid Prime (USA) (Rev 1)">
+		<category>Games</category>
+		<rom name="Metroid Prime (USA) (Rev 1).iso" size="1459978240" crc="00000000" sha1="00"/>
+	</game>
+	<game name="Legend of Zelda, The - The Wind Waker (USA, Europe)">
+		<rom name="Legend of Zelda, The - The Wind Waker (USA, Europe).iso" size="10" crc="` + crc + `" sha1="` + sha + `"/>
+	</game>
+</datafile>`
+
+	err = ioutil.WriteFile(path.Join(dirPath, "gamecube" + DatFileExtension), []byte(dat), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	provider := NewDatProvider(dirPath)
+
+	err = provider.Reload()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// A different extension should still match by name.
+	game, found, err := provider.Lookup(Query{FilePath: path.Join(dirPath, "Metroid Prime (USA) (Rev 1).rvz")})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := Game{
+		Title:      "Metroid Prime",
+		Categories: []string{"Nintendo - GameCube"},
+		Regions:    []string{"USA"},
+		Source:     "gamecube" + DatFileExtension,
+	}
+
+	if !found || !reflect.DeepEqual(game, expected) {
+		t.Fatal("Expected", expected, "- got", game, "found:", strconv.FormatBool(found))
+	}
+
+	game, found, err = provider.Lookup(Query{FilePath: renamedFilePath})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if !found || game.Title != "The Legend of Zelda - The Wind Waker" ||
+		!reflect.DeepEqual(game.Regions, []string{"USA", "Europe"}) {
+		t.Fatal("Renamed file should match by hash - got", game, "found:", strconv.FormatBool(found))
+	}
+
+	_, found, err = provider.Lookup(Query{FilePath: path.Join(dirPath, "gamecube" + DatFileExtension)})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if found {
+		t.Fatal("Unknown file should not be found")
+	}
+}
@@ -0,0 +1,3 @@
+// Package metadata provides information about games, such as their
+// canonical titles, from sources other than the games' settings.
+package metadata
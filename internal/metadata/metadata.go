@@ -0,0 +1,52 @@
+package metadata
+
+// Game is the information that a Provider knows about a game.
+type Game struct {
+	// Title is the game's canonical title.
+	Title string
+
+	// Categories are the categories that the game belongs to,
+	// such as the game's platform.
+	Categories []string
+
+	// Regions are the regions that the game was released in.
+	Regions []string
+
+	// Source describes where the information came from.
+	Source string
+}
+
+// Tags returns the game's categories followed by its regions.
+func (o Game) Tags() []string {
+	var tags []string
+
+	tags = append(tags, o.Categories...)
+	tags = append(tags, o.Regions...)
+
+	return tags
+}
+
+// Query identifies the game to look up.
+type Query struct {
+	// FilePath is the path to the game's primary file.
+	FilePath string
+
+	// CRC32 is the hex encoded CRC32 of the game's primary file.
+	// Providers compute it from the file if it is empty.
+	CRC32 string
+
+	// SHA1 is the hex encoded SHA1 of the game's primary file.
+	// Providers compute it from the file if it is empty.
+	SHA1 string
+}
+
+// Provider looks up information about games.
+type Provider interface {
+	// Name returns a human readable name for the provider.
+	Name() string
+
+	// Lookup returns the information that the provider has about
+	// the game. False is returned if the provider does not know
+	// about the game.
+	Lookup(query Query) (Game, bool, error)
+}
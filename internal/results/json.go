@@ -0,0 +1,106 @@
+package results
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	TextFormat      Format = "text"
+	JsonFormat      Format = "json"
+	JsonLinesFormat Format = "jsonl"
+)
+
+// Format describes how results are encoded.
+type Format string
+
+func (o Format) String() string {
+	return string(o)
+}
+
+// Formats returns the supported result formats.
+func Formats() []string {
+	return []string{
+		TextFormat.String(),
+		JsonFormat.String(),
+		JsonLinesFormat.String(),
+	}
+}
+
+type jsonResult struct {
+	Operation   Operation `json:"operation"`
+	Outcome     Outcome   `json:"outcome"`
+	GameName    string    `json:"game_name,omitempty"`
+	SteamUserId string    `json:"steam_user_id,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	GameDirPath string    `json:"game_dir_path,omitempty"`
+	AppId       string    `json:"app_id,omitempty"`
+	GridId      string    `json:"legacy_grid_id,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func (o *defaultResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(toJsonResult(o))
+}
+
+func toJsonResult(r Result) jsonResult {
+	return jsonResult{
+		Operation:   r.Operation(),
+		Outcome:     r.Outcome(),
+		GameName:    r.GameName(),
+		SteamUserId: r.SteamUserId(),
+		Reason:      r.Reason(),
+		GameDirPath: r.GameDirPath(),
+		AppId:       r.AppId(),
+		GridId:      r.LegacyGridId(),
+		Timestamp:   r.Time(),
+	}
+}
+
+// WriteJson writes the results to the provided io.Writer as a single
+// JSON array followed by a new line.
+func WriteJson(r []Result, w io.Writer) error {
+	encoded := make([]jsonResult, len(r))
+
+	for i := range r {
+		encoded[i] = toJsonResult(r[i])
+	}
+
+	return json.NewEncoder(w).Encode(encoded)
+}
+
+// WriteJsonLines writes each result to the provided io.Writer as a JSON
+// object on its own line.
+func WriteJsonLines(r []Result, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+
+	for i := range r {
+		err := encoder.Encode(toJsonResult(r[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write writes the results to the provided io.Writer using the
+// specified Format.
+func Write(r []Result, format Format, w io.Writer) error {
+	switch format {
+	case JsonFormat:
+		return WriteJson(r, w)
+	case JsonLinesFormat:
+		return WriteJsonLines(r, w)
+	}
+
+	for i := range r {
+		_, err := io.WriteString(w, r[i].PrintableResult() + "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
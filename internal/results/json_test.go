@@ -0,0 +1,108 @@
+package results
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteJsonLines(t *testing.T) {
+	r := WithGameDirPath([]Result{
+		NewDeleteSteamUserShortcutSuccess("Pikmin", "123", ""),
+		NewUpdateShortcutSkipped("Metroid Prime", "the game already exists"),
+	}, "/games/gamecube/Pikmin")
+
+	b := bytes.NewBuffer(nil)
+
+	err := WriteJsonLines(r, b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("Got unexpected number of lines -", len(lines))
+	}
+
+	var decoded map[string]interface{}
+
+	err = json.Unmarshal([]byte(lines[0]), &decoded)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := map[string]string{
+		"operation":     DeleteShortcut.String(),
+		"outcome":       Succeeded.String(),
+		"game_name":     "Pikmin",
+		"steam_user_id": "123",
+		"game_dir_path": "/games/gamecube/Pikmin",
+	}
+
+	for k, v := range exp {
+		if decoded[k] != v {
+			t.Fatal("Unexpected value for '" + k + "' -", decoded[k])
+		}
+	}
+
+	if _, hasReason := decoded["reason"]; hasReason {
+		t.Fatal("Empty reason should have been omitted")
+	}
+
+	if _, hasTimestamp := decoded["timestamp"]; !hasTimestamp {
+		t.Fatal("Missing timestamp")
+	}
+}
+
+func TestWriteJsonLinesUpdatedShortcutWithWarnings(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	err := WriteJsonLines([]Result{
+		NewUpdateSteamUserShortcutSuccessWarning("Pikmin", "123", "no icon was provided"),
+	}, b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var decoded map[string]interface{}
+
+	err = json.Unmarshal(b.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := map[string]string{
+		"operation":     UpdateShortcut.String(),
+		"outcome":       SucceededWithWarning.String(),
+		"game_name":     "Pikmin",
+		"steam_user_id": "123",
+		"reason":        "no icon was provided",
+	}
+
+	for k, v := range exp {
+		if decoded[k] != v {
+			t.Fatal("Unexpected value for '" + k + "' -", decoded[k])
+		}
+	}
+}
+
+func TestWriteJson(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+
+	err := WriteJson([]Result{NewCreateShortcutSuccess("Pikmin")}, b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var decoded []map[string]interface{}
+
+	err = json.Unmarshal(b.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(decoded) != 1 || decoded[0]["game_name"] != "Pikmin" {
+		t.Fatal("Got unexpected result -", b.String())
+	}
+}
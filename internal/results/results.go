@@ -2,12 +2,14 @@ package results
 
 import (
 	"bytes"
+	"time"
 )
 
 const (
 	DeleteShortcut Operation = "delete_shortcut"
 	UpdateShortcut Operation = "update_shortcut"
 	CreateShortcut Operation = "create_shortcut"
+	RenameShortcut Operation = "rename_shortcut"
 )
 
 type Operation string
@@ -21,6 +23,9 @@ const (
 	SucceededWithWarning Outcome = "succeeded with warning(s)"
 	Failed               Outcome = "failed"
 	Skipped              Outcome = "skipped"
+	Planned              Outcome = "planned"
+	Deferred             Outcome = "deferred"
+	Duplicate            Outcome = "skipped as a duplicate"
 )
 
 type Outcome string
@@ -36,6 +41,10 @@ type Result interface {
 	GameName() string
 	SteamUserId() string
 	Reason() string
+	GameDirPath() string
+	AppId() string
+	LegacyGridId() string
+	Time() time.Time
 }
 
 type defaultResult struct {
@@ -44,19 +53,32 @@ type defaultResult struct {
 	gameName  string
 	userId    string
 	reason    string
+	dirPath   string
+	appId     string
+	gridId    string
+	time      time.Time
 }
 
 func (o *defaultResult) PrintableResult() string {
 	buffer := bytes.NewBuffer(nil)
 	buffer.WriteString("Operation ")
 	buffer.WriteString(o.operation.String())
-	buffer.WriteString(" has ")
+	if o.result == Planned || o.result == Deferred {
+		buffer.WriteString(" is ")
+	} else {
+		buffer.WriteString(" has ")
+	}
 	buffer.WriteString(o.result.String())
 	if len(o.gameName) > 0 {
 		buffer.WriteString(" for game '")
 		buffer.WriteString(o.gameName)
 		buffer.WriteString("'")
 	}
+	if len(o.appId) > 0 {
+		buffer.WriteString(" (app ID '")
+		buffer.WriteString(o.appId)
+		buffer.WriteString("')")
+	}
 	if len(o.userId) > 0 {
 		buffer.WriteString(" for Steam user ID '")
 		buffer.WriteString(o.userId)
@@ -90,6 +112,49 @@ func (o *defaultResult) Reason() string {
 	return o.reason
 }
 
+func (o *defaultResult) GameDirPath() string {
+	return o.dirPath
+}
+
+func (o *defaultResult) AppId() string {
+	return o.appId
+}
+
+func (o *defaultResult) LegacyGridId() string {
+	return o.gridId
+}
+
+func (o *defaultResult) Time() time.Time {
+	return o.time
+}
+
+// WithGameDirPath sets the game directory path of each Result that does
+// not already have one.
+func WithGameDirPath(r []Result, gameDirPath string) []Result {
+	for i := range r {
+		d, ok := r[i].(*defaultResult)
+		if ok && len(d.dirPath) == 0 {
+			d.dirPath = gameDirPath
+		}
+	}
+
+	return r
+}
+
+// WithShortcutIds sets the shortcut app ID and legacy grid ID of each
+// Result that does not already have them.
+func WithShortcutIds(r []Result, appId string, legacyGridId string) []Result {
+	for i := range r {
+		d, ok := r[i].(*defaultResult)
+		if ok && len(d.appId) == 0 {
+			d.appId = appId
+			d.gridId = legacyGridId
+		}
+	}
+
+	return r
+}
+
 func NewDeleteSteamUserShortcutSuccess(gameName string, userId string, reason string) Result {
 	return &defaultResult{
 		operation: DeleteShortcut,
@@ -97,6 +162,7 @@ func NewDeleteSteamUserShortcutSuccess(gameName string, userId string, reason st
 		gameName:  gameName,
 		userId:    userId,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -107,6 +173,7 @@ func NewDeleteSteamUserShortcutSuccessWarning(gameName string, userId string, re
 		gameName:  gameName,
 		userId:    userId,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -117,6 +184,7 @@ func NewDeleteSteamUserShortcutFailure(gameName string, userId string, reason st
 		gameName:  gameName,
 		userId:    userId,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -127,6 +195,7 @@ func NewDeleteSteamUserShortcutSkipped(gameName string, userId string, reason st
 		gameName:  gameName,
 		userId:    userId,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -136,6 +205,7 @@ func NewDeleteShortcutSkipped(gameName string, reason string) Result {
 		result:    Skipped,
 		gameName:  gameName,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -145,6 +215,19 @@ func NewUpdateShortcutSkipped(gameName string, reason string) Result {
 		result:    Skipped,
 		gameName:  gameName,
 		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+// NewUpdateShortcutDuplicate returns a Result for a game that was not
+// added because it is identical to another game.
+func NewUpdateShortcutDuplicate(gameName string, reason string) Result {
+	return &defaultResult{
+		operation: UpdateShortcut,
+		result:    Duplicate,
+		gameName:  gameName,
+		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -154,6 +237,7 @@ func NewUpdateShortcutFailed(gameName string, reason string) Result {
 		result:    Failed,
 		gameName:  gameName,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -164,6 +248,7 @@ func NewUpdateSteamUserShortcutFailed(gameName string, userId string, reason str
 		gameName:  gameName,
 		userId:    userId,
 		reason:    reason,
+		time:      time.Now(),
 	}
 }
 
@@ -172,6 +257,7 @@ func NewUpdateShortcutSuccess(gameName string) Result {
 		operation: UpdateShortcut,
 		result:    Succeeded,
 		gameName:  gameName,
+		time:      time.Now(),
 	}
 }
 
@@ -180,6 +266,7 @@ func NewCreateShortcutSuccess(gameName string) Result {
 		operation: CreateShortcut,
 		result:    Succeeded,
 		gameName:  gameName,
+		time:      time.Now(),
 	}
 }
 
@@ -189,5 +276,136 @@ func NewCreateShortcutSuccessWithWarnings(gameName string, reason string) Result
 		result:    SucceededWithWarning,
 		gameName:  gameName,
 		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewCreateSteamUserShortcutSuccess(gameName string, userId string) Result {
+	return &defaultResult{
+		operation: CreateShortcut,
+		result:    Succeeded,
+		gameName:  gameName,
+		userId:    userId,
+		time:      time.Now(),
+	}
+}
+
+func NewCreateSteamUserShortcutSuccessWarning(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: CreateShortcut,
+		result:    SucceededWithWarning,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewUpdateSteamUserShortcutSuccess(gameName string, userId string) Result {
+	return &defaultResult{
+		operation: UpdateShortcut,
+		result:    Succeeded,
+		gameName:  gameName,
+		userId:    userId,
+		time:      time.Now(),
+	}
+}
+
+func NewUpdateSteamUserShortcutSuccessWarning(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: UpdateShortcut,
+		result:    SucceededWithWarning,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewCreateSteamUserShortcutPlanned(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: CreateShortcut,
+		result:    Planned,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewUpdateSteamUserShortcutPlanned(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: UpdateShortcut,
+		result:    Planned,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewDeleteSteamUserShortcutPlanned(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: DeleteShortcut,
+		result:    Planned,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewRenameSteamUserShortcutSuccess(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: RenameShortcut,
+		result:    Succeeded,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewRenameSteamUserShortcutSuccessWarning(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: RenameShortcut,
+		result:    SucceededWithWarning,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewRenameSteamUserShortcutPlanned(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: RenameShortcut,
+		result:    Planned,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewUpdateSteamUserShortcutDeferred(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: UpdateShortcut,
+		result:    Deferred,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
+	}
+}
+
+func NewDeleteSteamUserShortcutDeferred(gameName string, userId string, reason string) Result {
+	return &defaultResult{
+		operation: DeleteShortcut,
+		result:    Deferred,
+		gameName:  gameName,
+		userId:    userId,
+		reason:    reason,
+		time:      time.Now(),
 	}
 }
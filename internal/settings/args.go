@@ -0,0 +1,150 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+const (
+	GamePathPlaceholder     = "game_path"
+	GameDirPlaceholder      = "game_dir"
+	GameNamePlaceholder     = "game_name"
+	GameBasenamePlaceholder = "game_basename"
+	LauncherDirPlaceholder  = "launcher_dir"
+
+	// EnvPlaceholderPrefix is the prefix of placeholders that are
+	// replaced with an environment variable, as in '{env:HOME}'.
+	EnvPlaceholderPrefix = "env:"
+
+	placeholderStart = '{'
+	placeholderEnd   = '}'
+)
+
+// ArgsValues are the values of the placeholders in launcher arguments.
+type ArgsValues struct {
+	// GamePath is the path to the file that the launcher runs.
+	GamePath string
+
+	// GameDir is the path to the directory containing GamePath.
+	GameDir string
+
+	// GameName is the name of the game's shortcut.
+	GameName string
+
+	// GameBasename is the name of GamePath without its extension.
+	GameBasename string
+
+	// LauncherDir is the path to the directory containing
+	// the launcher's executable.
+	LauncherDir string
+}
+
+func (o ArgsValues) value(placeholder string) (string, bool) {
+	switch placeholder {
+	case GamePathPlaceholder:
+		return o.GamePath, true
+	case GameDirPlaceholder:
+		return o.GameDir, true
+	case GameNamePlaceholder:
+		return o.GameName, true
+	case GameBasenamePlaceholder:
+		return o.GameBasename, true
+	case LauncherDirPlaceholder:
+		return o.LauncherDir, true
+	}
+
+	if strings.HasPrefix(placeholder, EnvPlaceholderPrefix) {
+		return os.Getenv(strings.TrimPrefix(placeholder, EnvPlaceholderPrefix)), true
+	}
+
+	return "", false
+}
+
+// ValidateArgsTemplate returns a non-nil error if the launcher arguments
+// contain an unknown or unterminated placeholder.
+func ValidateArgsTemplate(args []string) error {
+	_, err := ExpandArgsTemplate(args, ArgsValues{})
+
+	return err
+}
+
+// ExpandArgsTemplate replaces the placeholders in each of the launcher
+// arguments with their values. A placeholder's value always stays within
+// its argument, even if it contains whitespace.
+func ExpandArgsTemplate(args []string, values ArgsValues) ([]string, error) {
+	expanded := make([]string, len(args))
+
+	for i := range args {
+		arg, err := expandArg(args[i], values)
+		if err != nil {
+			return nil, err
+		}
+
+		expanded[i] = arg
+	}
+
+	return expanded, nil
+}
+
+// expandArg replaces the placeholders in a launcher argument with their
+// values. Placeholders are surrounded by braces, as in '{game_path}'.
+// Literal braces are written as '{{' and '}}'.
+func expandArg(arg string, values ArgsValues) (string, error) {
+	var expanded []rune
+
+	runes := []rune(arg)
+
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case placeholderStart:
+			if i + 1 < len(runes) && runes[i + 1] == placeholderStart {
+				expanded = append(expanded, placeholderStart)
+				i++
+				continue
+			}
+
+			end := i + 1
+			for end < len(runes) && runes[end] != placeholderEnd {
+				end++
+			}
+
+			if end == len(runes) {
+				return "", errors.New("unterminated placeholder in argument '" + arg + "'")
+			}
+
+			placeholder := string(runes[i + 1:end])
+
+			value, ok := values.value(placeholder)
+			if !ok {
+				return "", errors.New("unknown placeholder '{" + placeholder + "}' in argument '" + arg + "'")
+			}
+
+			expanded = append(expanded, []rune(value)...)
+			i = end
+		case placeholderEnd:
+			if i + 1 < len(runes) && runes[i + 1] == placeholderEnd {
+				i++
+			}
+
+			expanded = append(expanded, placeholderEnd)
+		default:
+			expanded = append(expanded, runes[i])
+		}
+	}
+
+	return string(expanded), nil
+}
+
+// HasPlaceholder returns true if any of the launcher arguments contain
+// the placeholder.
+func HasPlaceholder(args []string, placeholder string) bool {
+	for _, arg := range args {
+		if strings.Contains(strings.Replace(arg, "{{", "", -1),
+			string(placeholderStart) + placeholder + string(placeholderEnd)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package settings
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestExpandArgsTemplate(t *testing.T) {
+	err := os.Setenv("GRUNDY_ARGS_TEST", "from env")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.Unsetenv("GRUNDY_ARGS_TEST")
+
+	values := ArgsValues{
+		GamePath:     "/games/Metroid Prime/Metroid Prime.iso",
+		GameDir:      "/games/Metroid Prime",
+		GameName:     "Metroid Prime",
+		GameBasename: "Metroid Prime",
+		LauncherDir:  "/opt/dolphin",
+	}
+
+	tests := []struct {
+		args     []string
+		expanded []string
+	}{
+		{nil, []string{}},
+		{[]string{"-b", "-e"}, []string{"-b", "-e"}},
+		{[]string{"-e", "{game_path}"}, []string{"-e", "/games/Metroid Prime/Metroid Prime.iso"}},
+		{[]string{"--dir={game_dir}/saves"}, []string{"--dir=/games/Metroid Prime/saves"}},
+		{[]string{"{game_name} ({game_basename})"}, []string{"Metroid Prime (Metroid Prime)"}},
+		{[]string{"-L", "{launcher_dir}/cores/core.so"}, []string{"-L", "/opt/dolphin/cores/core.so"}},
+		{[]string{"{env:GRUNDY_ARGS_TEST}"}, []string{"from env"}},
+		{[]string{"{{game_path}}"}, []string{"{game_path}"}},
+		{[]string{"{{{game_name}}}"}, []string{"{Metroid Prime}"}},
+		{[]string{"a}}b{{c"}, []string{"a}b{c"}},
+	}
+
+	for _, test := range tests {
+		expanded, err := ExpandArgsTemplate(test.args, values)
+		if err != nil {
+			t.Fatalf("failed to expand %q - %s", test.args, err.Error())
+		}
+
+		if !reflect.DeepEqual(expanded, test.expanded) {
+			t.Errorf("%q was expanded into %q - expected %q", test.args, expanded, test.expanded)
+		}
+	}
+}
+
+func TestValidateArgsTemplate(t *testing.T) {
+	tests := []struct {
+		args  []string
+		valid bool
+	}{
+		{[]string{"-e", "{game_path}"}, true},
+		{[]string{"{env:ANYTHING}"}, true},
+		{[]string{"{{not a placeholder}}"}, true},
+		{[]string{"{unknown}"}, false},
+		{[]string{"-e", "{game_path"}, false},
+		{[]string{"{GAME_PATH}"}, false},
+	}
+
+	for _, test := range tests {
+		err := ValidateArgsTemplate(test.args)
+		if (err == nil) != test.valid {
+			t.Errorf("validating %q returned %v - expected valid to be %t", test.args, err, test.valid)
+		}
+	}
+}
+
+func TestHasPlaceholder(t *testing.T) {
+	tests := []struct {
+		args []string
+		has  bool
+	}{
+		{nil, false},
+		{[]string{"-e"}, false},
+		{[]string{"-e", "{game_path}"}, true},
+		{[]string{"--file={game_path}"}, true},
+		{[]string{"{{game_path}}"}, false},
+		{[]string{"{game_dir}"}, false},
+	}
+
+	for _, test := range tests {
+		has := HasPlaceholder(test.args, GamePathPlaceholder)
+		if has != test.has {
+			t.Errorf("%q has game path placeholder returned %t - expected %t", test.args, has, test.has)
+		}
+	}
+}
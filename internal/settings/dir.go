@@ -12,6 +12,9 @@ const (
 	defaultSettingsDirname = ".grundy"
 	logFilesDirName        = "logs"
 	internalDirName        = ".internal"
+	shortcutsBackupsDir    = "shortcuts-backups"
+	pendingChangesFilename = "pending-shortcut-changes.json"
+	imageCacheDirName      = "image-cache"
 )
 
 func DirPath() string {
@@ -51,6 +54,24 @@ func InternalFilesDir(settingsDirPath string) string {
 	return path.Join(settingsDirPath, internalDirName)
 }
 
+// ShortcutsBackupsDir returns the path to the directory containing
+// backups of Steam users' shortcuts files.
+func ShortcutsBackupsDir(settingsDirPath string) string {
+	return path.Join(InternalFilesDir(settingsDirPath), shortcutsBackupsDir)
+}
+
+// PendingChangesFilePath returns the path to the file containing shortcut
+// changes that are waiting for Steam to exit.
+func PendingChangesFilePath(settingsDirPath string) string {
+	return path.Join(InternalFilesDir(settingsDirPath), pendingChangesFilename)
+}
+
+// ImageCacheDir returns the path to the directory containing images
+// that were converted or processed for Steam.
+func ImageCacheDir(settingsDirPath string) string {
+	return path.Join(InternalFilesDir(settingsDirPath), imageCacheDirName)
+}
+
 func CreateLogFilesDir(settingsDirPath string) (string, error) {
 	dirPath := path.Join(settingsDirPath, logFilesDirName)
 
@@ -0,0 +1,176 @@
+package settings
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	// RegexPatternPrefix marks a file pattern as a regular expression.
+	// Patterns without the prefix are globs.
+	RegexPatternPrefix = "re:"
+
+	patternSeparator = ','
+	patternEscape    = '\\'
+)
+
+// FileMatcher matches file names against a launcher's game file
+// patterns, suffixes, and excludes.
+type FileMatcher interface {
+	// Match returns true if the file name matches. The priority is the
+	// index of the first pattern that matched, which means that lower
+	// values are preferred. Suffixes are matched after all of the
+	// patterns, and share the lowest priority.
+	Match(filename string) (priority int, ok bool)
+}
+
+type filePattern struct {
+	glob   string
+	regex  *regexp.Regexp
+	suffix string
+}
+
+// matches returns true if the file name matches the pattern. Globs
+// are not case sensitive, while suffixes are.
+func (o filePattern) matches(filename string) bool {
+	if o.regex != nil {
+		return o.regex.MatchString(filename)
+	}
+
+	if len(o.suffix) > 0 {
+		return strings.HasSuffix(filename, o.suffix)
+	}
+
+	matches, _ := path.Match(o.glob, strings.ToLower(filename))
+
+	return matches
+}
+
+func compileFilePattern(pattern string) (filePattern, error) {
+	if strings.HasPrefix(pattern, RegexPatternPrefix) {
+		regex, err := regexp.Compile(strings.TrimPrefix(pattern, RegexPatternPrefix))
+		if err != nil {
+			return filePattern{}, errors.New("invalid regular expression '" + pattern + "' - " + err.Error())
+		}
+
+		return filePattern{regex: regex}, nil
+	}
+
+	glob := strings.ToLower(pattern)
+
+	_, err := path.Match(glob, "")
+	if err != nil {
+		return filePattern{}, errors.New("invalid glob '" + pattern + "' - " + err.Error())
+	}
+
+	return filePattern{glob: glob}, nil
+}
+
+type defaultFileMatcher struct {
+	includes       []filePattern
+	excludes       []filePattern
+	suffixPriority int
+}
+
+func (o *defaultFileMatcher) Match(filename string) (int, bool) {
+	for _, exclude := range o.excludes {
+		if exclude.matches(filename) {
+			return 0, false
+		}
+	}
+
+	for i, include := range o.includes {
+		if include.matches(filename) {
+			if len(include.suffix) > 0 {
+				return o.suffixPriority, true
+			}
+
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// NewFileMatcher returns a FileMatcher for the glob or regular expression
+// patterns, the suffixes, and the glob or regular expression excludes.
+// A file name that matches an exclude never matches.
+func NewFileMatcher(patterns []string, suffixes []string, excludes []string) (FileMatcher, error) {
+	m := &defaultFileMatcher{
+		suffixPriority: len(patterns),
+	}
+
+	for _, p := range patterns {
+		compiled, err := compileFilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+
+		m.includes = append(m.includes, compiled)
+	}
+
+	for _, s := range suffixes {
+		m.includes = append(m.includes, filePattern{suffix: s})
+	}
+
+	for _, p := range excludes {
+		compiled, err := compileFilePattern(p)
+		if err != nil {
+			return nil, err
+		}
+
+		m.excludes = append(m.excludes, compiled)
+	}
+
+	return m, nil
+}
+
+// splitPatterns splits a list of patterns. Commas that are part of
+// a pattern must be escaped with a backslash.
+func splitPatterns(data string) []string {
+	var patterns []string
+	var current []rune
+
+	add := func() {
+		p := strings.TrimSpace(string(current))
+		if len(p) > 0 {
+			patterns = append(patterns, p)
+		}
+		current = nil
+	}
+
+	runes := []rune(data)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] == patternEscape && i + 1 < len(runes) && runes[i + 1] == patternSeparator {
+			current = append(current, patternSeparator)
+			i++
+			continue
+		}
+
+		if runes[i] == patternSeparator {
+			add()
+			continue
+		}
+
+		current = append(current, runes[i])
+	}
+
+	add()
+
+	return patterns
+}
+
+// joinPatterns is the inverse of splitPatterns.
+func joinPatterns(patterns []string) string {
+	escaped := make([]string, len(patterns))
+
+	for i := range patterns {
+		escaped[i] = strings.Replace(patterns[i], string(patternSeparator),
+			string(patternEscape) + string(patternSeparator), -1)
+	}
+
+	return strings.Join(escaped, string(patternSeparator))
+}
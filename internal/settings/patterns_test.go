@@ -0,0 +1,85 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileMatcherMatch(t *testing.T) {
+	matcher, err := NewFileMatcher([]string{"*.rvz", "re:(?i)\\.(gcm|iso)$"},
+		[]string{".wbfs"}, []string{"*-sample.*"})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		filename string
+		priority int
+		ok       bool
+	}{
+		{"Metroid Prime.rvz", 0, true},
+		{"METROID PRIME.RVZ", 0, true},
+		{"Metroid Prime.iso", 1, true},
+		{"Metroid Prime.GCM", 1, true},
+		{"Metroid Prime.wbfs", 2, true},
+		{"Metroid Prime.WBFS", 0, false},
+		{"Metroid Prime-sample.iso", 0, false},
+		{"Metroid Prime-SAMPLE.rvz", 0, false},
+		{"Metroid Prime.txt", 0, false},
+		{"iso", 0, false},
+	}
+
+	for _, test := range tests {
+		priority, ok := matcher.Match(test.filename)
+		if ok != test.ok || priority != test.priority {
+			t.Errorf("'%s' matched with priority %d and ok %t - expected %d and %t",
+				test.filename, priority, ok, test.priority, test.ok)
+		}
+	}
+}
+
+func TestNewFileMatcherInvalidPatterns(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		excludes []string
+	}{
+		{[]string{"re:("}, nil},
+		{[]string{"[a-"}, nil},
+		{nil, []string{"re:[z-a]"}},
+	}
+
+	for _, test := range tests {
+		_, err := NewFileMatcher(test.patterns, nil, test.excludes)
+		if err == nil {
+			t.Errorf("expected an error for patterns %q and excludes %q", test.patterns, test.excludes)
+		}
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	tests := []struct {
+		data     string
+		patterns []string
+	}{
+		{"", nil},
+		{"*.iso", []string{"*.iso"}},
+		{" *.rvz , re:(?i)\\.(gcm|iso)$ ,, ", []string{"*.rvz", "re:(?i)\\.(gcm|iso)$"}},
+		{"re:^a{1\\,3}$, *.iso", []string{"re:^a{1,3}$", "*.iso"}},
+	}
+
+	for _, test := range tests {
+		patterns := splitPatterns(test.data)
+		if !reflect.DeepEqual(patterns, test.patterns) {
+			t.Errorf("'%s' was split into %q - expected %q", test.data, patterns, test.patterns)
+		}
+
+		if len(test.patterns) == 0 {
+			continue
+		}
+
+		rejoined := splitPatterns(joinPatterns(patterns))
+		if !reflect.DeepEqual(rejoined, test.patterns) {
+			t.Errorf("joined patterns were split into %q - expected %q", rejoined, test.patterns)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package settings
+
+import (
+	"os"
+	"runtime"
+)
+
+// LauncherPreset is a built-in launcher configuration for a commonly
+// used emulator. A launcher that names a preset starts with the preset's
+// settings, and can override any of them.
+type LauncherPreset struct {
+	// Name is the name of the preset, as in 'dolphin'.
+	Name string
+
+	// DisplayName is the name of the emulator, as in 'Dolphin'.
+	DisplayName string
+
+	// Type is the type of launcher that the preset creates.
+	Type LauncherType
+
+	// ExePaths are the usual locations of the emulator's executable,
+	// keyed by runtime.GOOS values. The paths may contain environment
+	// variables, as in '$LOCALAPPDATA'.
+	ExePaths map[string][]string
+
+	// DefaultArgs are the arguments that run a game without showing
+	// the emulator's user interface. The game's path is added to the
+	// end of the arguments.
+	DefaultArgs []string
+
+	// GameFilePatterns are the patterns of the files that the
+	// emulator can run, in order of priority. Glob patterns are
+	// used so that files are matched regardless of case.
+	GameFilePatterns []string
+}
+
+// ExePath returns the first of the preset's executable paths that exists
+// on the operating system, which is a runtime.GOOS value. If none of them
+// exist, the first path is returned. An empty string is returned if the
+// preset does not know where the executable is on the operating system.
+func (o LauncherPreset) ExePath(goos string) string {
+	var candidates []string
+
+	for _, p := range o.ExePaths[goos] {
+		expanded, ok := expandEnvStrict(p)
+		if !ok {
+			continue
+		}
+
+		_, err := os.Stat(expanded)
+		if err == nil {
+			return expanded
+		}
+
+		candidates = append(candidates, expanded)
+	}
+
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	return candidates[0]
+}
+
+// Launcher returns a launcher with the preset's settings for the
+// current operating system.
+func (o LauncherPreset) Launcher(name string) Launcher {
+	l := NewLauncher()
+
+	l.SetName(name)
+	l.SetPreset(o.Name)
+	o.apply(l)
+
+	return l
+}
+
+func (o LauncherPreset) apply(l Launcher) {
+	l.SetType(o.Type)
+	l.SetExePath(o.ExePath(runtime.GOOS))
+	l.SetDefaultArgs(o.DefaultArgs)
+	l.SetGameFilePatterns(o.GameFilePatterns)
+}
+
+// expandEnvStrict replaces the environment variables in a string. It
+// returns false if any of the variables are not set.
+func expandEnvStrict(s string) (string, bool) {
+	ok := true
+
+	expanded := os.Expand(s, func(name string) string {
+		value := os.Getenv(name)
+		if len(value) == 0 {
+			ok = false
+		}
+
+		return value
+	})
+
+	return expanded, ok
+}
+
+// LauncherPresets returns the built-in launcher presets.
+func LauncherPresets() []LauncherPreset {
+	presets := make([]LauncherPreset, len(launcherPresets))
+	copy(presets, launcherPresets)
+
+	return presets
+}
+
+// LauncherPresetNamed returns the built-in launcher preset with
+// the specified name.
+func LauncherPresetNamed(name string) (LauncherPreset, bool) {
+	for _, p := range launcherPresets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return LauncherPreset{}, false
+}
+
+// launcherPresets are the built-in launcher presets, sorted by name.
+var launcherPresets = []LauncherPreset{
+	{
+		Name:        "cemu",
+		DisplayName: "Cemu",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\Program Files\\Cemu\\Cemu.exe",
+				"$LOCALAPPDATA\\Cemu\\Cemu.exe",
+			},
+			"linux": {
+				"/usr/bin/cemu",
+				"/var/lib/flatpak/exports/bin/info.cemu.Cemu",
+			},
+			"darwin": {
+				"/Applications/Cemu.app/Contents/MacOS/Cemu",
+			},
+		},
+		DefaultArgs:      []string{"-f", "-g"},
+		GameFilePatterns: []string{"*.wua", "*.wud", "*.wux", "*.rpx", "*.iso"},
+	},
+	{
+		Name:        "dolphin",
+		DisplayName: "Dolphin",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\Program Files\\Dolphin\\Dolphin.exe",
+				"C:\\Program Files\\Dolphin-x64\\Dolphin.exe",
+			},
+			"linux": {
+				"/usr/bin/dolphin-emu",
+				"/usr/games/dolphin-emu",
+				"/var/lib/flatpak/exports/bin/org.DolphinEmu.dolphin-emu",
+			},
+			"darwin": {
+				"/Applications/Dolphin.app/Contents/MacOS/Dolphin",
+			},
+		},
+		DefaultArgs:      []string{"-b", "-e"},
+		GameFilePatterns: []string{"*.rvz", "*.gcm", "*.iso", "*.wbfs", "*.ciso", "*.gcz", "*.wia", "*.wad", "*.dol", "*.elf"},
+	},
+	{
+		Name:        "duckstation",
+		DisplayName: "DuckStation",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"$LOCALAPPDATA\\Programs\\DuckStation\\duckstation-qt-x64-ReleaseLTCG.exe",
+				"C:\\Program Files\\DuckStation\\duckstation-qt-x64-ReleaseLTCG.exe",
+			},
+			"linux": {
+				"/usr/bin/duckstation-qt",
+				"/var/lib/flatpak/exports/bin/org.duckstation.DuckStation",
+			},
+			"darwin": {
+				"/Applications/DuckStation.app/Contents/MacOS/DuckStation",
+			},
+		},
+		DefaultArgs:      []string{"-batch", "-fullscreen", "--"},
+		GameFilePatterns: []string{"*.m3u", "*.chd", "*.cue", "*.pbp", "*.iso", "*.ecm", "*.mds", "*.img"},
+	},
+	{
+		Name:        "pcsx2",
+		DisplayName: "PCSX2",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\Program Files\\PCSX2\\pcsx2-qt.exe",
+			},
+			"linux": {
+				"/usr/bin/pcsx2-qt",
+				"/var/lib/flatpak/exports/bin/net.pcsx2.PCSX2",
+			},
+			"darwin": {
+				"/Applications/PCSX2.app/Contents/MacOS/PCSX2",
+			},
+		},
+		DefaultArgs:      []string{"-batch", "-nogui", "-fullscreen", "--"},
+		GameFilePatterns: []string{"*.chd", "*.iso", "*.cso", "*.zso", "*.gz", "*.bin", "*.elf"},
+	},
+	{
+		Name:        "ppsspp",
+		DisplayName: "PPSSPP",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\Program Files\\PPSSPP\\PPSSPPWindows64.exe",
+			},
+			"linux": {
+				"/usr/bin/PPSSPPSDL",
+				"/usr/bin/ppsspp",
+				"/var/lib/flatpak/exports/bin/org.ppsspp.PPSSPP",
+			},
+			"darwin": {
+				"/Applications/PPSSPPSDL.app/Contents/MacOS/PPSSPPSDL",
+			},
+		},
+		DefaultArgs:      []string{"--fullscreen"},
+		GameFilePatterns: []string{"*.iso", "*.cso", "*.chd", "*.pbp", "*.elf", "*.prx"},
+	},
+	{
+		Name:        "retroarch",
+		DisplayName: "RetroArch",
+		Type:        RetroArchLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\RetroArch-Win64\\retroarch.exe",
+				"C:\\Program Files\\RetroArch\\retroarch.exe",
+			},
+			"linux": {
+				"/usr/bin/retroarch",
+				"/var/lib/flatpak/exports/bin/org.libretro.RetroArch",
+			},
+			"darwin": {
+				"/Applications/RetroArch.app/Contents/MacOS/RetroArch",
+			},
+		},
+		DefaultArgs: []string{"--fullscreen"},
+	},
+	{
+		Name:        "rpcs3",
+		DisplayName: "RPCS3",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\RPCS3\\rpcs3.exe",
+				"C:\\Program Files\\RPCS3\\rpcs3.exe",
+			},
+			"linux": {
+				"/usr/bin/rpcs3",
+				"/var/lib/flatpak/exports/bin/net.rpcs3.RPCS3",
+			},
+			"darwin": {
+				"/Applications/RPCS3.app/Contents/MacOS/rpcs3",
+			},
+		},
+		DefaultArgs:      []string{"--no-gui"},
+		GameFilePatterns: []string{"eboot.bin", "*.iso"},
+	},
+	{
+		Name:        "ryujinx",
+		DisplayName: "Ryujinx",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"C:\\Program Files\\Ryujinx\\Ryujinx.exe",
+			},
+			"linux": {
+				"/usr/bin/ryujinx",
+				"/usr/bin/Ryujinx",
+			},
+			"darwin": {
+				"/Applications/Ryujinx.app/Contents/MacOS/Ryujinx",
+			},
+		},
+		DefaultArgs:      []string{"--fullscreen"},
+		GameFilePatterns: []string{"*.nsp", "*.xci", "*.nca", "*.nro", "*.nso"},
+	},
+	{
+		Name:        "yuzu",
+		DisplayName: "yuzu and its forks",
+		Type:        GenericLauncher,
+		ExePaths: map[string][]string{
+			"windows": {
+				"$LOCALAPPDATA\\yuzu\\yuzu-windows-msvc\\yuzu.exe",
+				"C:\\Program Files\\yuzu\\yuzu.exe",
+			},
+			"linux": {
+				"/usr/bin/yuzu",
+				"/var/lib/flatpak/exports/bin/org.yuzu_emu.yuzu",
+			},
+			"darwin": {
+				"/Applications/yuzu.app/Contents/MacOS/yuzu",
+			},
+		},
+		DefaultArgs:      []string{"-f", "-g"},
+		GameFilePatterns: []string{"*.nsp", "*.xci", "*.nca", "*.nro", "*.nso"},
+	},
+}
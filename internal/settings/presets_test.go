@@ -0,0 +1,157 @@
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLauncherPresetsApplyAndOverride(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-presets-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	dolphin, ok := LauncherPresetNamed("dolphin")
+	if !ok {
+		t.Fatal("The dolphin preset does not exist")
+	}
+
+	retroArch, ok := LauncherPresetNamed("retroarch")
+	if !ok {
+		t.Fatal("The retroarch preset does not exist")
+	}
+
+	tests := []struct {
+		section      string
+		launcherType LauncherType
+		exePath      string
+		args         []string
+		suffixes     []string
+		patterns     []string
+	}{
+		{
+			section:      "preset = dolphin\n",
+			launcherType: GenericLauncher,
+			exePath:      dolphin.ExePath(runtime.GOOS),
+			args:         dolphin.DefaultArgs,
+			patterns:     dolphin.GameFilePatterns,
+		},
+		{
+			section: "preset = dolphin\n" +
+				"exe_path = /opt/dolphin/dolphin-emu\n" +
+				"game_file_suffixes = .rvz, .iso\n",
+			launcherType: GenericLauncher,
+			exePath:      "/opt/dolphin/dolphin-emu",
+			args:         dolphin.DefaultArgs,
+			suffixes:     []string{".rvz", ".iso"},
+		},
+		{
+			section: "preset = dolphin\n" +
+				"game_file_patterns = *.rvz\n" +
+				"default_args =\n",
+			launcherType: GenericLauncher,
+			exePath:      dolphin.ExePath(runtime.GOOS),
+			patterns:     []string{"*.rvz"},
+		},
+		{
+			section: "preset = dolphin\n" +
+				"type = retroarch\n" +
+				"default_args = --verbose\n",
+			launcherType: RetroArchLauncher,
+			exePath:      dolphin.ExePath(runtime.GOOS),
+			args:         []string{"--verbose"},
+			patterns:     dolphin.GameFilePatterns,
+		},
+		{
+			section:      "preset = retroarch\n",
+			launcherType: RetroArchLauncher,
+			exePath:      retroArch.ExePath(runtime.GOOS),
+			args:         retroArch.DefaultArgs,
+		},
+	}
+
+	isEqual := func(a []string, b []string) bool {
+		return (len(a) == 0 && len(b) == 0) || reflect.DeepEqual(a, b)
+	}
+
+	for i, test := range tests {
+		filePath := path.Join(dirPath, "launchers.ini")
+
+		err := ioutil.WriteFile(filePath, []byte("[emulator]\n" + test.section), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		launchers := NewLaunchersSettings()
+
+		err = launchers.Reload(filePath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		l, ok := launchers.Has("emulator")
+		if !ok {
+			t.Fatalf("%d: the launcher does not exist", i)
+		}
+
+		args, err := l.DefaultArgs()
+		if err != nil {
+			t.Fatalf("%d: %s", i, err.Error())
+		}
+
+		if l.Type() != test.launcherType {
+			t.Errorf("%d: type is '%s' - expected '%s'", i, l.Type(), test.launcherType)
+		}
+
+		if l.ExePath() != test.exePath {
+			t.Errorf("%d: exe path is '%s' - expected '%s'", i, l.ExePath(), test.exePath)
+		}
+
+		if !isEqual(args, test.args) {
+			t.Errorf("%d: default args are %q - expected %q", i, args, test.args)
+		}
+
+		if !isEqual(l.GameFileSuffixes(), test.suffixes) {
+			t.Errorf("%d: game file suffixes are %q - expected %q", i, l.GameFileSuffixes(), test.suffixes)
+		}
+
+		if !isEqual(l.GameFilePatterns(), test.patterns) {
+			t.Errorf("%d: game file patterns are %q - expected %q", i, l.GameFilePatterns(), test.patterns)
+		}
+	}
+}
+
+func TestLauncherPresetsMatchFilesRegardlessOfCase(t *testing.T) {
+	for _, preset := range LauncherPresets() {
+		matcher, err := preset.Launcher(preset.Name).GameFileMatcher()
+		if err != nil {
+			t.Fatalf("preset '%s' has invalid game file patterns - %s", preset.Name, err.Error())
+		}
+
+		for _, pattern := range preset.GameFilePatterns {
+			filename := strings.ToUpper(strings.Replace(pattern, "*", "game", 1))
+
+			_, isGameFile := matcher.Match(filename)
+			if !isGameFile {
+				t.Errorf("preset '%s' does not match '%s'", preset.Name, filename)
+			}
+		}
+	}
+}
+
+func TestUnknownLauncherPresetIsInvalid(t *testing.T) {
+	l := NewLauncher()
+	l.SetName("emulator")
+	l.SetPreset("not-a-preset")
+
+	err := l.IsValid()
+	if err == nil {
+		t.Fatal("A launcher with an unknown preset should be invalid")
+	}
+}
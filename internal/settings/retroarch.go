@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	retroArchCoreSuffix     = "_libretro"
+	retroArchInfoExtension  = ".info"
+	retroArchCoresDirName   = "cores"
+	retroArchInfoDirName    = "info"
+	retroArchConfigDirName  = "retroarch"
+	retroArchCoreArg        = "-L"
+	retroArchListSeparator  = "|"
+	retroArchDisplayNameKey = "display_name"
+	retroArchExtensionsKey  = "supported_extensions"
+)
+
+var (
+	retroArchCoreExtensions = []string{".dll", ".so", ".dylib"}
+)
+
+// RetroArchCore is a libretro core that is installed in RetroArch's
+// cores directory.
+type RetroArchCore struct {
+	// Name is the name of the core's file without its extension,
+	// as in 'snes9x_libretro'.
+	Name string
+
+	// FilePath is the path to the core's file.
+	FilePath string
+
+	// DisplayName is the core's name according to its info file.
+	// It is empty if the core does not have an info file.
+	DisplayName string
+
+	// Extensions are the extensions of the files that the core
+	// can load according to its info file, as in '.sfc'.
+	Extensions []string
+}
+
+// Is returns true if the name refers to the core. The name may be the
+// core's file name, with or without its extension, and with or without
+// the '_libretro' suffix.
+func (o RetroArchCore) Is(name string) bool {
+	name = strings.ToLower(path.Base(strings.Replace(name, "\\", "/", -1)))
+
+	for _, ext := range retroArchCoreExtensions {
+		name = strings.TrimSuffix(name, ext)
+	}
+
+	return strings.TrimSuffix(name, retroArchCoreSuffix) ==
+		strings.TrimSuffix(strings.ToLower(o.Name), retroArchCoreSuffix)
+}
+
+// RetroArchCores returns the cores in the cores directory, sorted by name.
+// A core's info file is looked for in the info directory and then in
+// the cores directory.
+func RetroArchCores(coresDirPath string, infoDirPath string) ([]RetroArchCore, error) {
+	infos, err := ioutil.ReadDir(coresDirPath)
+	if err != nil {
+		return nil, errors.New("failed to read RetroArch cores directory - " + err.Error())
+	}
+
+	var cores []RetroArchCore
+
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		name, isCore := retroArchCoreName(info.Name())
+		if !isCore {
+			continue
+		}
+
+		core := RetroArchCore{
+			Name:     name,
+			FilePath: path.Join(coresDirPath, info.Name()),
+		}
+
+		for _, dirPath := range []string{infoDirPath, coresDirPath} {
+			err := core.loadInfo(path.Join(dirPath, name + retroArchInfoExtension))
+			if err == nil {
+				break
+			}
+
+			if !os.IsNotExist(err) {
+				return nil, errors.New("failed to read info file for RetroArch core '" +
+					name + "' - " + err.Error())
+			}
+		}
+
+		cores = append(cores, core)
+	}
+
+	sort.Slice(cores, func(i int, j int) bool {
+		return cores[i].Name < cores[j].Name
+	})
+
+	return cores, nil
+}
+
+// retroArchCoreName returns the name of a core from its file name.
+func retroArchCoreName(filename string) (string, bool) {
+	for _, ext := range retroArchCoreExtensions {
+		if strings.HasSuffix(filename, ext) {
+			name := strings.TrimSuffix(filename, ext)
+
+			return name, strings.HasSuffix(name, retroArchCoreSuffix)
+		}
+	}
+
+	return "", false
+}
+
+// loadInfo loads the core's display name and extensions from a core info
+// file. Info files contain lines such as 'display_name = "Snes9x"'.
+func (o *RetroArchCore) loadInfo(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.Trim(strings.TrimSpace(parts[1]), "\"")
+
+		switch strings.TrimSpace(parts[0]) {
+		case retroArchDisplayNameKey:
+			o.DisplayName = value
+		case retroArchExtensionsKey:
+			o.Extensions = nil
+			for _, ext := range strings.Split(value, retroArchListSeparator) {
+				ext = strings.TrimSpace(ext)
+				if len(ext) > 0 {
+					o.Extensions = append(o.Extensions, "." + ext)
+				}
+			}
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,147 @@
+package settings
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestRetroArchCores(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-retroarch-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	coresDirPath := path.Join(dirPath, "cores")
+	infoDirPath := path.Join(dirPath, "info")
+
+	files := map[string]string{
+		path.Join(coresDirPath, "snes9x_libretro.so"):  "",
+		path.Join(coresDirPath, "mgba_libretro.dll"):   "",
+		path.Join(coresDirPath, "genesis_plus_gx.so"):  "",
+		path.Join(coresDirPath, "readme.txt"):          "",
+		path.Join(infoDirPath, "snes9x_libretro.info"): "# Software Information\n" +
+			"display_name = \"Nintendo - SNES / SFC (Snes9x - Current)\"\n" +
+			"supported_extensions = \"smc|sfc|swc|fig\"\n",
+		path.Join(coresDirPath, "mgba_libretro.info"): "display_name=\"Nintendo - Game Boy Advance (mGBA)\"\n" +
+			"supported_extensions = \"gba | gb|\"\n" +
+			"not a key value pair\n",
+	}
+
+	for filePath, data := range files {
+		err := os.MkdirAll(path.Dir(filePath), 0755)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		err = ioutil.WriteFile(filePath, []byte(data), 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	cores, err := RetroArchCores(coresDirPath, infoDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := []RetroArchCore{
+		{
+			Name:        "mgba_libretro",
+			FilePath:    path.Join(coresDirPath, "mgba_libretro.dll"),
+			DisplayName: "Nintendo - Game Boy Advance (mGBA)",
+			Extensions:  []string{".gba", ".gb"},
+		},
+		{
+			Name:        "snes9x_libretro",
+			FilePath:    path.Join(coresDirPath, "snes9x_libretro.so"),
+			DisplayName: "Nintendo - SNES / SFC (Snes9x - Current)",
+			Extensions:  []string{".smc", ".sfc", ".swc", ".fig"},
+		},
+	}
+
+	if !reflect.DeepEqual(cores, expected) {
+		t.Fatalf("Unexpected cores - got %+v - expected %+v", cores, expected)
+	}
+
+	_, err = RetroArchCores(path.Join(dirPath, "missing"), infoDirPath)
+	if err == nil {
+		t.Fatal("Expected an error for a missing cores directory")
+	}
+}
+
+func TestRetroArchCoreIs(t *testing.T) {
+	core := RetroArchCore{Name: "snes9x_libretro"}
+
+	tests := []struct {
+		name string
+		is   bool
+	}{
+		{"snes9x", true},
+		{"snes9x_libretro", true},
+		{"snes9x_libretro.so", true},
+		{"snes9x_libretro.dll", true},
+		{"SNES9X_Libretro.DYLIB", true},
+		{"/usr/lib/libretro/snes9x_libretro.so", true},
+		{"C:\\RetroArch-Win64\\cores\\snes9x_libretro.dll", true},
+		{"snes9x2010", false},
+		{"snes9x_libretro.txt", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		is := core.Is(test.name)
+		if is != test.is {
+			t.Errorf("'%s' matching core '%s' returned %t - expected %t", test.name, core.Name, is, test.is)
+		}
+	}
+}
+
+func TestWithRetroArchCoreReusesDiscoveredCores(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-retroarch-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	for _, filename := range []string{"snes9x_libretro.so", "mgba_libretro.so"} {
+		err := ioutil.WriteFile(path.Join(dirPath, filename), nil, 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	launcher := NewLauncher()
+	launcher.SetName("retroarch")
+	launcher.SetType(RetroArchLauncher)
+	launcher.SetRetroArchCoresDirPath(dirPath)
+
+	snes := launcher.WithRetroArchCore("snes9x")
+
+	core, ok := snes.RetroArchCore()
+	if !ok || core.Name != "snes9x_libretro" {
+		t.Fatal("Expected the snes9x core - got", core, ok)
+	}
+
+	// The cores directory is not read again, so a launcher's cores
+	// stay the same while it is being used.
+	err = os.Remove(path.Join(dirPath, "mgba_libretro.so"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	core, ok = launcher.WithRetroArchCore("mgba").RetroArchCore()
+	if !ok || core.Name != "mgba_libretro" {
+		t.Fatal("Expected the previously discovered mgba core - got", core, ok)
+	}
+
+	launcher.SetRetroArchCoresDirPath(dirPath)
+
+	_, ok = launcher.WithRetroArchCore("mgba").RetroArchCore()
+	if ok {
+		t.Fatal("Changing the cores directory should discover the cores again")
+	}
+}
@@ -1,6 +1,7 @@
 package settings
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/ioutil"
@@ -8,8 +9,12 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+	"github.com/stephen-fox/grundy/internal/cmdline"
 )
 
 const (
@@ -23,10 +28,33 @@ const (
 	none            section = ""
 	appSettings     section = "settings"
 	gameCollections section = "game_collections"
-
+	allowedUsers    section = "allowed_steam_users"
+	deniedUsers     section = "denied_steam_users"
+	collectionModes section = "game_collection_modes"
+	layouts         section = "game_collection_layouts"
+	depths          section = "game_collection_depths"
+	groupCategories section = "game_collection_group_categories"
+	namePatterns    section = "game_collection_name_patterns"
+	collectionCores section = "game_collection_retroarch_cores"
+	knownAppIds     section = "app_ids"
+	knownGridIds    section = "legacy_grid_ids"
+	knownCrc32s     section = "crc32s"
+	knownSha1s      section = "sha1s"
+	knownFiles      section = "hashed_files"
+
+	appSteamRootDirPath key = "steam_root"
+	appCloseSteam       key = "close_steam_for_changes"
+	appImageFit         key = "image_fit"
+
+	launcherPreset           key = "preset"
+	launcherType             key = "type"
 	launcherExePath          key = "exe_path"
 	launcherDefaultArgs      key = "default_args"
 	launcherGameFileSuffixes key = "game_file_suffixes"
+	launcherGameFilePatterns key = "game_file_patterns"
+	launcherGameFileExcludes key = "game_file_excludes"
+	launcherCoresDirPath     key = "retroarch_cores_dir"
+	launcherInfoDirPath      key = "retroarch_info_dir"
 
 	gameName           key = "name"
 	gameExeSubPath     key = "exe"
@@ -35,21 +63,79 @@ const (
 	gameIconPath       key = "icon"
 	gameCategories     key = "categories"
 	gameGridImagePath  key = "grid"
-
-	listSeparator  = ","
-	gameIconPrefix = "-icon"
-	gameGridPrefix = "-grid"
-
-	pngSuffix = ".png"
-	jpgSuffix = ".jpg"
+	gamePortraitPath   key = "portrait"
+	gameHeroPath       key = "hero"
+	gameLogoPath       key = "logo"
+	gameAllowedUsers   key = "allowed_steam_users"
+	gameDeniedUsers    key = "denied_steam_users"
+	gameRetroArchCore  key = "retroarch_core"
+
+	GenericLauncher   LauncherType = "generic"
+	RetroArchLauncher LauncherType = "retroarch"
+
+	SubdirectoriesLayout CollectionLayout = "subdirectories"
+	FlatLayout           CollectionLayout = "flat"
+
+	DirectoryMode CollectionMode = "directory"
+	PerFileMode   CollectionMode = "per_file"
+	PlaylistMode  CollectionMode = "m3u"
+
+	NamePatternName = "{name}"
+	NamePatternFile = "{file}"
+
+	listSeparator      = ","
+	gameIconPrefix     = "-icon"
+	gameGridPrefix     = "-grid"
+	gamePortraitPrefix = "-portrait"
+	gameHeroPrefix     = "-hero"
+	gameLogoPrefix     = "-logo"
+
+	pngSuffix  = ".png"
+	jpgSuffix  = ".jpg"
+	jpegSuffix = ".jpeg"
+	webpSuffix = ".webp"
+	icoSuffix  = ".ico"
 )
 
 var (
-	gameIconSuffixes      = []string{gameIconPrefix + pngSuffix, gameIconPrefix + jpgSuffix}
-	gameGridImageSuffixes = []string{gameGridPrefix + pngSuffix, gameGridPrefix + jpgSuffix}
-	GameImageSuffixes     = append(gameIconSuffixes, gameGridImageSuffixes...)
+	// imageSuffixes are the image file extensions that are discovered in
+	// game directories. Images that Steam cannot display are converted
+	// to PNG when they are installed.
+	imageSuffixes = []string{pngSuffix, jpgSuffix, jpegSuffix, webpSuffix, icoSuffix}
+
+	gameIconSuffixes      = prefixAll(gameIconPrefix, imageSuffixes)
+	gameGridImageSuffixes = prefixAll(gameGridPrefix, imageSuffixes)
+	gamePortraitSuffixes  = prefixAll(gamePortraitPrefix, imageSuffixes)
+	gameHeroSuffixes      = prefixAll(gameHeroPrefix, imageSuffixes)
+	gameLogoSuffixes      = prefixAll(gameLogoPrefix, imageSuffixes)
+	GameImageSuffixes     = joinLists(gameIconSuffixes, gameGridImageSuffixes,
+		gamePortraitSuffixes, gameHeroSuffixes, gameLogoSuffixes)
 )
 
+// LauncherType determines how a launcher's arguments and game files
+// are configured.
+type LauncherType string
+
+func (o LauncherType) String() string {
+	return string(o)
+}
+
+// CollectionLayout determines how the games in a game collection
+// are organized.
+type CollectionLayout string
+
+func (o CollectionLayout) String() string {
+	return string(o)
+}
+
+// CollectionMode determines how many shortcuts are created for each
+// game in a game collection.
+type CollectionMode string
+
+func (o CollectionMode) String() string {
+	return string(o)
+}
+
 type section string
 
 func (o section) string() string {
@@ -100,6 +186,27 @@ type AppSettings interface {
 	AddGameCollection(dirPath string, launcherName string)
 	RemoveGameCollection(dirPath string)
 	HasGameCollection(dirPath string) (launcherName string, ok bool)
+	GameCollectionOf(p string) (dirPath string, launcherName string, ok bool)
+	SetSteamRootDirPath(dirPath string)
+	SteamRootDirPath() string
+	SetCloseSteamForChanges(shouldClose bool)
+	CloseSteamForChanges() bool
+	SetImageFit(fit string)
+	ImageFit() string
+	SetGameCollectionSteamUsers(dirPath string, allowed []string, denied []string)
+	GameCollectionSteamUsers(dirPath string) (allowed []string, denied []string)
+	SetGameCollectionDepth(dirPath string, depth int)
+	GameCollectionDepth(dirPath string) int
+	SetGameCollectionGroupCategories(dirPath string, enabled bool)
+	GameCollectionGroupCategories(dirPath string) bool
+	SetGameCollectionLayout(dirPath string, layout CollectionLayout)
+	GameCollectionLayout(dirPath string) CollectionLayout
+	SetGameCollectionMode(dirPath string, mode CollectionMode)
+	GameCollectionMode(dirPath string) CollectionMode
+	SetGameCollectionNamePattern(dirPath string, pattern string)
+	GameCollectionNamePattern(dirPath string) string
+	SetGameCollectionRetroArchCore(dirPath string, coreName string)
+	GameCollectionRetroArchCore(dirPath string) string
 }
 
 type defaultAppSettings struct {
@@ -150,6 +257,159 @@ func (o *defaultAppSettings) HasGameCollection(dirPath string) (string, bool) {
 	return o.config.KeyValue(gameCollections, key(dirPath)), true
 }
 
+// GameCollectionOf returns the game collection that contains the file
+// or directory. If game collections are nested, the innermost game
+// collection is returned.
+func (o *defaultAppSettings) GameCollectionOf(p string) (string, string, bool) {
+	var dirPath string
+	var launcherName string
+
+	for collectionDirPath, name := range o.GameCollectionsPathsToLauncherNames() {
+		if len(collectionDirPath) <= len(dirPath) || !isInDir(p, collectionDirPath) {
+			continue
+		}
+
+		dirPath = collectionDirPath
+		launcherName = name
+	}
+
+	return dirPath, launcherName, len(dirPath) > 0
+}
+
+func (o *defaultAppSettings) SetSteamRootDirPath(dirPath string) {
+	o.config.AddOrUpdateKeyValue(appSettings, appSteamRootDirPath, dirPath)
+}
+
+func (o *defaultAppSettings) SteamRootDirPath() string {
+	return o.config.KeyValue(appSettings, appSteamRootDirPath)
+}
+
+func (o *defaultAppSettings) SetCloseSteamForChanges(shouldClose bool) {
+	o.config.AddOrUpdateKeyValue(appSettings, appCloseSteam, strconv.FormatBool(shouldClose))
+}
+
+// CloseSteamForChanges returns true if Steam should be asked to exit
+// when shortcut changes are waiting for it to exit.
+func (o *defaultAppSettings) CloseSteamForChanges() bool {
+	shouldClose, _ := strconv.ParseBool(o.config.KeyValue(appSettings, appCloseSteam))
+
+	return shouldClose
+}
+
+func (o *defaultAppSettings) SetImageFit(fit string) {
+	o.config.AddOrUpdateKeyValue(appSettings, appImageFit, fit)
+}
+
+// ImageFit returns what should be done to images that do not have
+// the dimensions that Steam expects.
+func (o *defaultAppSettings) ImageFit() string {
+	return o.config.KeyValue(appSettings, appImageFit)
+}
+
+func (o *defaultAppSettings) SetGameCollectionSteamUsers(dirPath string, allowed []string, denied []string) {
+	o.config.DeleteKey(allowedUsers, key(dirPath))
+	if len(allowed) > 0 {
+		o.config.AddOrUpdateKeyValue(allowedUsers, key(dirPath), strings.Join(allowed, listSeparator))
+	}
+
+	o.config.DeleteKey(deniedUsers, key(dirPath))
+	if len(denied) > 0 {
+		o.config.AddOrUpdateKeyValue(deniedUsers, key(dirPath), strings.Join(denied, listSeparator))
+	}
+}
+
+func (o *defaultAppSettings) GameCollectionSteamUsers(dirPath string) ([]string, []string) {
+	return splitList(o.config.KeyValue(allowedUsers, key(dirPath))),
+		splitList(o.config.KeyValue(deniedUsers, key(dirPath)))
+}
+
+func (o *defaultAppSettings) SetGameCollectionDepth(dirPath string, depth int) {
+	o.config.AddOrUpdateKeyValue(depths, key(dirPath), strconv.Itoa(depth))
+}
+
+// GameCollectionDepth returns the maximum number of grouping directories
+// between the game collection and its games. For example, a depth of 1
+// allows games to be organized as 'collection/USA/My Game'.
+func (o *defaultAppSettings) GameCollectionDepth(dirPath string) int {
+	depth, err := strconv.Atoi(strings.TrimSpace(o.config.KeyValue(depths, key(dirPath))))
+	if err != nil || depth < 0 {
+		return 0
+	}
+
+	return depth
+}
+
+func (o *defaultAppSettings) SetGameCollectionGroupCategories(dirPath string, enabled bool) {
+	o.config.AddOrUpdateKeyValue(groupCategories, key(dirPath), strconv.FormatBool(enabled))
+}
+
+// GameCollectionGroupCategories returns true if the names of the
+// grouping directories that contain a game should be added to the
+// game's Steam categories.
+func (o *defaultAppSettings) GameCollectionGroupCategories(dirPath string) bool {
+	enabled, _ := strconv.ParseBool(o.config.KeyValue(groupCategories, key(dirPath)))
+
+	return enabled
+}
+
+func (o *defaultAppSettings) SetGameCollectionLayout(dirPath string, layout CollectionLayout) {
+	o.config.AddOrUpdateKeyValue(layouts, key(dirPath), layout.String())
+}
+
+// GameCollectionLayout returns the game collection's layout. Games are
+// subdirectories of the collection unless the layout is FlatLayout, in
+// which case each of the collection's game files is a game.
+func (o *defaultAppSettings) GameCollectionLayout(dirPath string) CollectionLayout {
+	if CollectionLayout(o.config.KeyValue(layouts, key(dirPath))) == FlatLayout {
+		return FlatLayout
+	}
+
+	return SubdirectoriesLayout
+}
+
+func (o *defaultAppSettings) SetGameCollectionMode(dirPath string, mode CollectionMode) {
+	o.config.AddOrUpdateKeyValue(collectionModes, key(dirPath), mode.String())
+}
+
+// GameCollectionMode returns the game collection's mode. Unknown
+// modes are treated as DirectoryMode.
+func (o *defaultAppSettings) GameCollectionMode(dirPath string) CollectionMode {
+	switch mode := CollectionMode(o.config.KeyValue(collectionModes, key(dirPath))); mode {
+	case PerFileMode, PlaylistMode:
+		return mode
+	}
+
+	return DirectoryMode
+}
+
+func (o *defaultAppSettings) SetGameCollectionNamePattern(dirPath string, pattern string) {
+	o.config.AddOrUpdateKeyValue(namePatterns, key(dirPath), pattern)
+}
+
+// GameCollectionNamePattern returns the pattern used to name the
+// shortcuts of a PerFileMode game collection. NamePatternName is
+// replaced with the game's name, and NamePatternFile is replaced with
+// the game file's name without its extension.
+func (o *defaultAppSettings) GameCollectionNamePattern(dirPath string) string {
+	pattern := o.config.KeyValue(namePatterns, key(dirPath))
+	if len(pattern) == 0 {
+		return NamePatternFile
+	}
+
+	return pattern
+}
+
+func (o *defaultAppSettings) SetGameCollectionRetroArchCore(dirPath string, coreName string) {
+	o.config.AddOrUpdateKeyValue(collectionCores, key(dirPath), coreName)
+}
+
+// GameCollectionRetroArchCore returns the name of the RetroArch core that
+// runs the games in the game collection. It is empty if the collection
+// does not have a core.
+func (o *defaultAppSettings) GameCollectionRetroArchCore(dirPath string) string {
+	return o.config.KeyValue(collectionCores, key(dirPath))
+}
+
 type LaunchersSettings interface {
 	SaveableSettings
 	Has(name string) (Launcher, bool)
@@ -178,28 +438,63 @@ func (o *defaultLaunchersSettings) ResetToDefaults() {
 	o.config.Clear()
 }
 
+// Example returns launchers settings with an example launcher, and
+// a launcher for each of the built-in launcher presets.
 func (o *defaultLaunchersSettings) Example() SaveableSettings {
-	s := NewLaunchersSettings()
+	s := &defaultLaunchersSettings{
+		config: newEmptyIniFile(),
+	}
 
 	s.AddOrUpdate(NewLauncher().Example())
 
+	for _, preset := range LauncherPresets() {
+		s.AddOrUpdate(preset.Launcher(preset.Name))
+		s.config.SetSectionComment(section(preset.Name), preset.DisplayName)
+	}
+
 	return s
 }
 
 func (o *defaultLaunchersSettings) Has(name string) (Launcher, bool) {
-	l := NewLauncher()
+	l := &defaultLauncherSettings{}
+
+	l.ResetToDefaults()
 
 	sec := section(name)
 
 	if o.config.HasSection(sec) {
 		l.SetName(name)
-		l.SetExePath(o.config.KeyValue(sec, launcherExePath))
-		l.SetDefaultArgs(o.config.KeyValue(sec, launcherDefaultArgs))
-		suffixes := o.config.KeyValue(sec, launcherGameFileSuffixes)
-		if len(suffixes) > 0 {
-			l.SetGameFileSuffixes(strings.Split(suffixes, listSeparator))
+
+		// A preset provides the initial settings, and any key in
+		// the launcher's section overrides the preset's value.
+		l.SetPreset(o.config.KeyValue(sec, launcherPreset))
+		preset, hasPreset := LauncherPresetNamed(l.Preset())
+		if hasPreset {
+			preset.apply(l)
 		}
 
+		if o.config.HasKey(sec, launcherType) {
+			l.SetType(LauncherType(o.config.KeyValue(sec, launcherType)))
+		}
+		if o.config.HasKey(sec, launcherExePath) {
+			l.SetExePath(o.config.KeyValue(sec, launcherExePath))
+		}
+		if o.config.HasKey(sec, launcherDefaultArgs) {
+			l.defaultArgs, l.defaultArgsErr = cmdline.Split(o.config.KeyValue(sec, launcherDefaultArgs))
+		}
+		// The game file suffixes and patterns override the preset's
+		// game files together. Otherwise, the preset's patterns would
+		// still match the files that the suffixes leave out.
+		if o.config.HasKey(sec, launcherGameFileSuffixes) || o.config.HasKey(sec, launcherGameFilePatterns) {
+			l.SetGameFileSuffixes(splitList(o.config.KeyValue(sec, launcherGameFileSuffixes)))
+			l.SetGameFilePatterns(splitPatterns(o.config.KeyValue(sec, launcherGameFilePatterns)))
+		}
+		if o.config.HasKey(sec, launcherGameFileExcludes) {
+			l.SetGameFileExcludes(splitPatterns(o.config.KeyValue(sec, launcherGameFileExcludes)))
+		}
+		l.SetRetroArchCoresDirPath(o.config.KeyValue(sec, launcherCoresDirPath))
+		l.SetRetroArchInfoDirPath(o.config.KeyValue(sec, launcherInfoDirPath))
+
 		return l, true
 	}
 
@@ -209,9 +504,28 @@ func (o *defaultLaunchersSettings) Has(name string) (Launcher, bool) {
 func (o *defaultLaunchersSettings) AddOrUpdate(l Launcher) {
 	sec := section(l.Name())
 
+	if len(l.Preset()) > 0 {
+		o.config.AddOrUpdateKeyValue(sec, launcherPreset, l.Preset())
+	}
+	if l.Type() != GenericLauncher {
+		o.config.AddOrUpdateKeyValue(sec, launcherType, l.Type().String())
+	}
 	o.config.AddOrUpdateKeyValue(sec, launcherExePath, l.ExePath())
-	o.config.AddOrUpdateKeyValue(sec, launcherDefaultArgs, l.DefaultArgs())
+	args, _ := l.DefaultArgs()
+	o.config.AddOrUpdateKeyValue(sec, launcherDefaultArgs, cmdline.Format(args))
 	o.config.AddOrUpdateKeyValue(sec, launcherGameFileSuffixes, strings.Join(l.GameFileSuffixes(), listSeparator))
+	if len(l.GameFilePatterns()) > 0 {
+		o.config.AddOrUpdateKeyValue(sec, launcherGameFilePatterns, joinPatterns(l.GameFilePatterns()))
+	}
+	if len(l.GameFileExcludes()) > 0 {
+		o.config.AddOrUpdateKeyValue(sec, launcherGameFileExcludes, joinPatterns(l.GameFileExcludes()))
+	}
+	if len(l.RetroArchCoresDirPath()) > 0 {
+		o.config.AddOrUpdateKeyValue(sec, launcherCoresDirPath, l.RetroArchCoresDirPath())
+	}
+	if len(l.RetroArchInfoDirPath()) > 0 {
+		o.config.AddOrUpdateKeyValue(sec, launcherInfoDirPath, l.RetroArchInfoDirPath())
+	}
 }
 
 func (o *defaultLaunchersSettings) Remove(l Launcher) {
@@ -224,27 +538,72 @@ type Launcher interface {
 	IsValid() error
 	SetName(string)
 	Name() string
+	SetPreset(string)
+	Preset() string
+	SetType(LauncherType)
+	Type() LauncherType
 	SetExePath(string)
 	ExePath() string
 	ExeDirPath() string
-	SetDefaultArgs(string)
-	DefaultArgs() string
+	SetDefaultArgs([]string)
+	DefaultArgs() ([]string, error)
 	SetGameFileSuffixes([]string)
 	GameFileSuffixes() []string
+	SetGameFilePatterns([]string)
+	GameFilePatterns() []string
+	SetGameFileExcludes([]string)
+	GameFileExcludes() []string
+	GameFileMatcher() (FileMatcher, error)
+	SetRetroArchCoresDirPath(string)
+	RetroArchCoresDirPath() string
+	SetRetroArchInfoDirPath(string)
+	RetroArchInfoDirPath() string
+	RetroArchCores() ([]RetroArchCore, error)
+	WithRetroArchCore(coreName string) Launcher
+	RetroArchCore() (RetroArchCore, bool)
 }
 
 type defaultLauncherSettings struct {
 	name             string
+	preset           string
+	launcherType     LauncherType
 	exePath          string
-	defaultArgs      string
+	defaultArgs      []string
+	defaultArgsErr   error
 	gameFileSuffixes []string
+	gameFilePatterns []string
+	gameFileExcludes []string
+	coresDirPath     string
+	infoDirPath      string
+	discovered       *discoveredRetroArchCores
+	core             *RetroArchCore
+	coreErr          error
+}
+
+// discoveredRetroArchCores are the cores found in a launcher's cores
+// directory. They are shared by the launcher's copies, so that the
+// directory is only read once for each launcher.
+type discoveredRetroArchCores struct {
+	done  bool
+	cores []RetroArchCore
+	err   error
 }
 
 func (o *defaultLauncherSettings) ResetToDefaults() {
 	o.name = ""
+	o.preset = ""
+	o.launcherType = GenericLauncher
 	o.exePath = ""
+	o.coresDirPath = ""
+	o.infoDirPath = ""
+	o.discovered = &discoveredRetroArchCores{}
+	o.core = nil
+	o.coreErr = nil
 	o.gameFileSuffixes = []string{}
-	o.defaultArgs = ""
+	o.gameFilePatterns = []string{}
+	o.gameFileExcludes = []string{}
+	o.defaultArgs = []string{}
+	o.defaultArgsErr = nil
 }
 
 func (o *defaultLauncherSettings) Example() Launcher {
@@ -260,7 +619,7 @@ func (o *defaultLauncherSettings) Example() Launcher {
 		launcher.SetGameFileSuffixes([]string{".sh", ".bin"})
 	}
 
-	launcher.SetDefaultArgs("")
+	launcher.SetDefaultArgs([]string{})
 
 	return launcher
 }
@@ -270,6 +629,14 @@ func (o *defaultLauncherSettings) IsValid() error {
 		return errors.New("Missing name field")
 	}
 
+	if len(o.preset) > 0 {
+		_, hasPreset := LauncherPresetNamed(o.preset)
+		if !hasPreset {
+			return errors.New("The '" + launcherPreset.string() + "' field is invalid - unknown preset '" +
+				o.preset + "'")
+		}
+	}
+
 	if len(o.exePath) == 0 {
 		return errors.New("The '" + launcherExePath.string() + "' field is missing or is empty")
 	}
@@ -279,8 +646,33 @@ func (o *defaultLauncherSettings) IsValid() error {
 		return errors.New("Executable does not exist - " + err.Error())
 	}
 
-	if len(o.gameFileSuffixes) == 0 {
-		return errors.New("The '" + launcherGameFileSuffixes.string() + "' field is missing or is empty")
+	if o.launcherType != GenericLauncher && o.launcherType != RetroArchLauncher {
+		return errors.New("The '" + launcherType.string() + "' field is invalid - unknown launcher type '" +
+			o.launcherType.String() + "'")
+	}
+
+	if o.coreErr != nil {
+		return o.coreErr
+	}
+
+	if len(o.GameFileSuffixes()) == 0 && len(o.gameFilePatterns) == 0 {
+		return errors.New("The '" + launcherGameFileSuffixes.string() + "' and '" +
+			launcherGameFilePatterns.string() + "' fields are missing or are empty")
+	}
+
+	_, err = o.GameFileMatcher()
+	if err != nil {
+		return errors.New("The game file patterns are invalid - " + err.Error())
+	}
+
+	args, err := o.DefaultArgs()
+	if err != nil {
+		return err
+	}
+
+	err = ValidateArgsTemplate(args)
+	if err != nil {
+		return errors.New("The '" + launcherDefaultArgs.string() + "' field is invalid - " + err.Error())
 	}
 
 	return nil
@@ -294,6 +686,29 @@ func (o *defaultLauncherSettings) Name() string {
 	return o.name
 }
 
+func (o *defaultLauncherSettings) SetPreset(name string) {
+	o.preset = name
+}
+
+// Preset returns the name of the built-in launcher preset that the
+// launcher's settings are based on. It is empty if the launcher
+// does not use a preset.
+func (o *defaultLauncherSettings) Preset() string {
+	return o.preset
+}
+
+func (o *defaultLauncherSettings) SetType(launcherType LauncherType) {
+	if len(launcherType) == 0 {
+		launcherType = GenericLauncher
+	}
+
+	o.launcherType = launcherType
+}
+
+func (o *defaultLauncherSettings) Type() LauncherType {
+	return o.launcherType
+}
+
 func (o *defaultLauncherSettings) SetExePath(filePath string) {
 	o.exePath = filePath
 }
@@ -306,46 +721,229 @@ func (o *defaultLauncherSettings) ExeDirPath() string {
 	return path.Dir(o.ExePath())
 }
 
-func (o *defaultLauncherSettings) SetDefaultArgs(args string) {
+func (o *defaultLauncherSettings) SetDefaultArgs(args []string) {
 	o.defaultArgs = args
+	o.defaultArgsErr = nil
 }
 
-func (o *defaultLauncherSettings) DefaultArgs() string {
-	return o.defaultArgs
+func (o *defaultLauncherSettings) DefaultArgs() ([]string, error) {
+	if o.coreErr != nil {
+		return nil, o.coreErr
+	}
+
+	if o.defaultArgsErr != nil {
+		return nil, errors.New("The '" + launcherDefaultArgs.string() + "' field is invalid - " + o.defaultArgsErr.Error())
+	}
+
+	if o.core != nil {
+		return append([]string{retroArchCoreArg, o.core.FilePath}, o.defaultArgs...), nil
+	}
+
+	return o.defaultArgs, nil
 }
 
 func (o *defaultLauncherSettings) SetGameFileSuffixes(suffixes []string) {
 	o.gameFileSuffixes = suffixes
 }
 
+// GameFileSuffixes returns the suffixes of the launcher's game files.
+// If a RetroArch launcher does not have any suffixes or patterns, the
+// suffixes are the extensions supported by its RetroArch core.
 func (o *defaultLauncherSettings) GameFileSuffixes() []string {
+	if len(o.gameFileSuffixes) == 0 && len(o.gameFilePatterns) == 0 && o.core != nil {
+		return o.core.Extensions
+	}
+
 	return o.gameFileSuffixes
 }
 
+func (o *defaultLauncherSettings) SetGameFilePatterns(patterns []string) {
+	o.gameFilePatterns = patterns
+}
+
+// GameFilePatterns returns the glob and regular expression patterns that
+// match the launcher's game files, in order of priority.
+func (o *defaultLauncherSettings) GameFilePatterns() []string {
+	return o.gameFilePatterns
+}
+
+func (o *defaultLauncherSettings) SetGameFileExcludes(patterns []string) {
+	o.gameFileExcludes = patterns
+}
+
+// GameFileExcludes returns the glob and regular expression patterns of
+// files that are never game files.
+func (o *defaultLauncherSettings) GameFileExcludes() []string {
+	return o.gameFileExcludes
+}
+
+// GameFileMatcher returns a FileMatcher for the launcher's game files.
+func (o *defaultLauncherSettings) GameFileMatcher() (FileMatcher, error) {
+	return NewFileMatcher(o.gameFilePatterns, o.GameFileSuffixes(), o.gameFileExcludes)
+}
+
+func (o *defaultLauncherSettings) SetRetroArchCoresDirPath(dirPath string) {
+	o.coresDirPath = dirPath
+	o.discovered = &discoveredRetroArchCores{}
+}
+
+// RetroArchCoresDirPath returns the path to the directory containing
+// RetroArch's cores. It is empty if the default directory is used.
+func (o *defaultLauncherSettings) RetroArchCoresDirPath() string {
+	return o.coresDirPath
+}
+
+func (o *defaultLauncherSettings) SetRetroArchInfoDirPath(dirPath string) {
+	o.infoDirPath = dirPath
+	o.discovered = &discoveredRetroArchCores{}
+}
+
+// RetroArchInfoDirPath returns the path to the directory containing
+// the cores' info files. It is empty if the default directory is used.
+func (o *defaultLauncherSettings) RetroArchInfoDirPath() string {
+	return o.infoDirPath
+}
+
+// retroArchCoresDirPath returns the RetroArch cores directory. It defaults to the
+// 'cores' directory next to the launcher's executable. If that does not
+// exist, the cores directory in the user's RetroArch configuration
+// directory is used instead (if there is one).
+func (o *defaultLauncherSettings) retroArchCoresDirPath() string {
+	if len(o.coresDirPath) > 0 {
+		return o.coresDirPath
+	}
+
+	dirPath := path.Join(o.ExeDirPath(), retroArchCoresDirName)
+
+	_, err := os.Stat(dirPath)
+	if err == nil {
+		return dirPath
+	}
+
+	configDirPath, err := os.UserConfigDir()
+	if err != nil {
+		return dirPath
+	}
+
+	userDirPath := path.Join(filepath.ToSlash(configDirPath), retroArchConfigDirName, retroArchCoresDirName)
+
+	_, err = os.Stat(userDirPath)
+	if err != nil {
+		return dirPath
+	}
+
+	return userDirPath
+}
+
+// retroArchInfoDirPath returns the directory containing the cores' info
+// files. It defaults to the 'info' directory next to the cores directory.
+func (o *defaultLauncherSettings) retroArchInfoDirPath() string {
+	if len(o.infoDirPath) > 0 {
+		return o.infoDirPath
+	}
+
+	return path.Join(path.Dir(o.retroArchCoresDirPath()), retroArchInfoDirName)
+}
+
+// RetroArchCores returns the cores that are installed in the launcher's
+// cores directory. The directory is only read the first time that the
+// launcher, or one of its copies, discovers its cores. Get the launcher
+// from the LaunchersSettings again to discover newly installed cores.
+func (o *defaultLauncherSettings) RetroArchCores() ([]RetroArchCore, error) {
+	if o.discovered == nil {
+		o.discovered = &discoveredRetroArchCores{}
+	}
+
+	if !o.discovered.done {
+		o.discovered.cores, o.discovered.err = RetroArchCores(o.retroArchCoresDirPath(), o.retroArchInfoDirPath())
+		o.discovered.done = true
+	}
+
+	return o.discovered.cores, o.discovered.err
+}
+
+// WithRetroArchCore returns a copy of a RetroArchLauncher that runs games
+// with the named core. The core's file is added to the default arguments,
+// and its extensions become the default game file suffixes. The launcher
+// is returned as it is if it is not a RetroArchLauncher or if the name is
+// empty. The copy is invalid if the core is not installed.
+func (o *defaultLauncherSettings) WithRetroArchCore(coreName string) Launcher {
+	if o.launcherType != RetroArchLauncher || len(coreName) == 0 {
+		return o
+	}
+
+	c := *o
+	c.core = nil
+	c.coreErr = nil
+
+	cores, err := o.RetroArchCores()
+	if err != nil {
+		c.coreErr = err
+		return &c
+	}
+
+	for i := range cores {
+		if cores[i].Is(coreName) {
+			c.core = &cores[i]
+			return &c
+		}
+	}
+
+	c.coreErr = errors.New("RetroArch core '" + coreName + "' is not installed in '" +
+		o.retroArchCoresDirPath() + "'")
+
+	return &c
+}
+
+// RetroArchCore returns the core that was selected using WithRetroArchCore.
+func (o *defaultLauncherSettings) RetroArchCore() (RetroArchCore, bool) {
+	if o.core == nil {
+		return RetroArchCore{}, false
+	}
+
+	return *o.core, true
+}
+
 type GameSettings interface {
 	SaveableSettings
 	SetName(string)
 	Name() string
+	HasName() bool
 	SetExeSubPath(string)
 	ExeFullPath(launcher Launcher) (filePath string, exists bool)
+	ExeFullPaths(launcher Launcher) []string
 	ShouldOverrideLauncherArgs() bool
-	SetLauncherOverrideArgs(string)
-	LauncherOverrideArgs() string
-	SetAdditionalLauncherArgs(string)
-	AdditionalLauncherArgs() string
+	SetLauncherOverrideArgs([]string)
+	LauncherOverrideArgs() ([]string, error)
+	SetAdditionalLauncherArgs([]string)
+	AdditionalLauncherArgs() ([]string, error)
 	SetIconPath(string)
 	IconPath() DynamicFilePath
 	SetGridImagePath(string)
 	GridImagePath() DynamicFilePath
+	SetPortraitImagePath(string)
+	PortraitImagePath() DynamicFilePath
+	SetHeroImagePath(string)
+	HeroImagePath() DynamicFilePath
+	SetLogoImagePath(string)
+	LogoImagePath() DynamicFilePath
 	AddCategory(string)
 	RemoveCategory(string)
 	SetCategories([]string)
 	Categories() []string
+	HasCategories() bool
+	SetAllowedSteamUsers([]string)
+	AllowedSteamUsers() []string
+	SetDeniedSteamUsers([]string)
+	DeniedSteamUsers() []string
+	SetRetroArchCore(string)
+	RetroArchCore() string
 }
 
 type defaultGameSettings struct {
-	dirPath string
-	config  configFile
+	dirPath  string
+	filePath string
+	config   configFile
 }
 
 func (o *defaultGameSettings) Filename(additionalSuffix string) string {
@@ -368,20 +966,29 @@ func (o *defaultGameSettings) Example() SaveableSettings {
 	s := NewGameSettings("")
 
 	s.SetName("example-game")
-	s.SetAdditionalLauncherArgs("")
-	s.SetLauncherOverrideArgs("")
+	s.SetAdditionalLauncherArgs([]string{})
+	s.SetLauncherOverrideArgs([]string{})
+	s.SetRetroArchCore("")
 
 	if runtime.GOOS == "windows" {
 		s.SetExeSubPath("example.exe")
 		s.SetIconPath("C:\\path\\to\\game-icon.png")
 		s.SetGridImagePath("C:\\path\\to\\game-grid.png")
+		s.SetPortraitImagePath("C:\\path\\to\\game-portrait.png")
+		s.SetHeroImagePath("C:\\path\\to\\game-hero.png")
+		s.SetLogoImagePath("C:\\path\\to\\game-logo.png")
 	} else {
 		s.SetExeSubPath("example.sh")
 		s.SetIconPath("/path/to/game-icon.png")
 		s.SetGridImagePath("/path/to/game-grid.png")
+		s.SetPortraitImagePath("/path/to/game-portrait.png")
+		s.SetHeroImagePath("/path/to/game-hero.png")
+		s.SetLogoImagePath("/path/to/game-logo.png")
 	}
 
 	s.SetCategories([]string{"My Cool Category", "Another Cool Category", "some-other category"})
+	s.SetAllowedSteamUsers([]string{})
+	s.SetDeniedSteamUsers([]string{})
 
 	return s
 }
@@ -396,9 +1003,19 @@ func (o *defaultGameSettings) Name() string {
 		return name
 	}
 
+	if len(o.filePath) > 0 {
+		return o.baseName()
+	}
+
 	return path.Base(o.dirPath)
 }
 
+// HasName returns true if the game's name is set in its settings rather
+// than being derived from its directory.
+func (o *defaultGameSettings) HasName() bool {
+	return len(o.config.KeyValue(none, gameName)) > 0
+}
+
 func (o *defaultGameSettings) SetExeSubPath(p string) {
 	o.config.AddOrUpdateKeyValue(none, gameExeSubPath, p)
 }
@@ -409,6 +1026,8 @@ func (o *defaultGameSettings) ExeFullPath(launcher Launcher) (string, bool) {
 
 	if len(strings.TrimSpace(exeSubPath)) > 0 {
 		exeFullPath = filepath.Join(o.dirPath, exeSubPath)
+	} else if len(o.filePath) > 0 {
+		exeFullPath = o.filePath
 	} else {
 		found := false
 		exeFullPath, found = o.defaultExeFullPath(launcher)
@@ -420,52 +1039,119 @@ func (o *defaultGameSettings) ExeFullPath(launcher Launcher) (string, bool) {
 	// TODO: Does this handle Windows disk drives properly?
 	exeFullPath = filepath.Clean(exeFullPath)
 
-	_, statErr := os.Stat(exeFullPath)
-	if statErr != nil {
+	info, statErr := os.Stat(exeFullPath)
+	if statErr != nil || (len(o.filePath) > 0 && info.IsDir()) {
 		return exeFullPath, false
 	}
 
 	return exeFullPath, true
 }
 
+// ExeFullPaths returns the paths to each of the game's files that the
+// launcher can run, sorted by name. Only the executable is returned if
+// it is set in the game's settings, or if the game is a single file.
+func (o *defaultGameSettings) ExeFullPaths(launcher Launcher) []string {
+	if len(strings.TrimSpace(o.config.KeyValue(none, gameExeSubPath))) > 0 || len(o.filePath) > 0 {
+		exeFullPath, exists := o.ExeFullPath(launcher)
+		if !exists {
+			return nil
+		}
+
+		return []string{exeFullPath}
+	}
+
+	var exeFullPaths []string
+
+	for _, f := range o.gameFiles(launcher) {
+		exeFullPaths = append(exeFullPaths, f.filePath)
+	}
+
+	return exeFullPaths
+}
+
+// defaultExeFullPath returns the path to the game file that matches the
+// launcher's highest priority pattern. If several files match the same
+// pattern, the first one by name is returned.
 func (o *defaultGameSettings) defaultExeFullPath(launcher Launcher) (string, bool) {
-	exeFunc := func(filename string) bool {
-		suffixes := launcher.GameFileSuffixes()
-		for i := range suffixes {
-			if strings.HasSuffix(filename, suffixes[i]) {
-				return true
-			}
+	var best gameFile
+	found := false
+
+	for _, f := range o.gameFiles(launcher) {
+		if !found || f.priority < best.priority {
+			best = f
+			found = true
 		}
+	}
 
-		return false
+	return best.filePath, found
+}
+
+// gameFile is a file that a launcher can run.
+type gameFile struct {
+	filePath string
+	priority int
+}
+
+// gameFiles returns the files in the game's directory that the launcher
+// can run, sorted by name.
+func (o *defaultGameSettings) gameFiles(launcher Launcher) []gameFile {
+	matcher, err := launcher.GameFileMatcher()
+	if err != nil {
+		return nil
 	}
 
-	exeFilePath, found := dirContainsFile(o.dirPath, exeFunc)
-	if found {
-		return exeFilePath, true
+	infos, err := ioutil.ReadDir(o.dirPath)
+	if err != nil {
+		return nil
 	}
 
-	return "", false
+	var files []gameFile
+
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+
+		priority, matches := matcher.Match(info.Name())
+		if matches {
+			files = append(files, gameFile{
+				filePath: path.Join(o.dirPath, info.Name()),
+				priority: priority,
+			})
+		}
+	}
+
+	return files
 }
 
 func (o *defaultGameSettings) ShouldOverrideLauncherArgs() bool {
-	return len(o.LauncherOverrideArgs()) > 0
+	return len(o.config.KeyValue(none, gameOverrideArgs)) > 0
 }
 
-func (o *defaultGameSettings) SetLauncherOverrideArgs(args string) {
-	o.config.AddOrUpdateKeyValue(none, gameOverrideArgs, args)
+func (o *defaultGameSettings) SetLauncherOverrideArgs(args []string) {
+	o.config.AddOrUpdateKeyValue(none, gameOverrideArgs, cmdline.Format(args))
 }
 
-func (o *defaultGameSettings) LauncherOverrideArgs() string {
-	return o.config.KeyValue(none, gameOverrideArgs)
+func (o *defaultGameSettings) LauncherOverrideArgs() ([]string, error) {
+	args, err := cmdline.Split(o.config.KeyValue(none, gameOverrideArgs))
+	if err != nil {
+		return nil, errors.New("The '" + gameOverrideArgs.string() + "' field is invalid - " + err.Error())
+	}
+
+	return args, nil
 }
 
-func (o *defaultGameSettings) SetAdditionalLauncherArgs(args string) {
-	o.config.AddOrUpdateKeyValue(none, gameAdditionalArgs, args)
+func (o *defaultGameSettings) SetAdditionalLauncherArgs(args []string) {
+	o.config.AddOrUpdateKeyValue(none, gameAdditionalArgs, cmdline.Format(args))
 }
 
-func (o *defaultGameSettings) AdditionalLauncherArgs() string {
-	return o.config.KeyValue(none, gameAdditionalArgs)
+func (o *defaultGameSettings) AdditionalLauncherArgs() ([]string, error) {
+	args, err := cmdline.Split(o.config.KeyValue(none, gameAdditionalArgs))
+	if err != nil {
+		return nil, errors.New("The '" + gameAdditionalArgs.string() + "' field is invalid - " + err.Error())
+	}
+
+	return args, nil
 }
 
 func (o *defaultGameSettings) SetIconPath(filePath string) {
@@ -484,6 +1170,30 @@ func (o *defaultGameSettings) GridImagePath() DynamicFilePath {
 	return o.manualFilePathOrExisting(gameGridImagePath, gameGridImageSuffixes)
 }
 
+func (o *defaultGameSettings) SetPortraitImagePath(filePath string) {
+	o.config.AddOrUpdateKeyValue(none, gamePortraitPath, filePath)
+}
+
+func (o *defaultGameSettings) PortraitImagePath() DynamicFilePath {
+	return o.manualFilePathOrExisting(gamePortraitPath, gamePortraitSuffixes)
+}
+
+func (o *defaultGameSettings) SetHeroImagePath(filePath string) {
+	o.config.AddOrUpdateKeyValue(none, gameHeroPath, filePath)
+}
+
+func (o *defaultGameSettings) HeroImagePath() DynamicFilePath {
+	return o.manualFilePathOrExisting(gameHeroPath, gameHeroSuffixes)
+}
+
+func (o *defaultGameSettings) SetLogoImagePath(filePath string) {
+	o.config.AddOrUpdateKeyValue(none, gameLogoPath, filePath)
+}
+
+func (o *defaultGameSettings) LogoImagePath() DynamicFilePath {
+	return o.manualFilePathOrExisting(gameLogoPath, gameLogoSuffixes)
+}
+
 func (o *defaultGameSettings) manualFilePathOrExisting(k key, suffixes []string) DynamicFilePath {
 	result := &defaultDynamicFilePath{
 		filePath: o.config.KeyValue(none, k),
@@ -491,7 +1201,11 @@ func (o *defaultGameSettings) manualFilePathOrExisting(k key, suffixes []string)
 
 	if len(strings.TrimSpace(result.filePath)) == 0 {
 		result.dynamic = true
-		result.filePath, result.fileExists = existingFilePath(o.dirPath, suffixes)
+		if len(o.filePath) > 0 {
+			result.filePath, result.fileExists = existingSiblingFilePath(o.dirPath, o.baseName(), suffixes)
+		} else {
+			result.filePath, result.fileExists = existingFilePath(o.dirPath, suffixes)
+		}
 		if !result.fileExists {
 			return result
 		}
@@ -510,6 +1224,13 @@ func (o *defaultGameSettings) manualFilePathOrExisting(k key, suffixes []string)
 	return result
 }
 
+// baseName returns the name of the game's file without its extension.
+func (o *defaultGameSettings) baseName() string {
+	filename := path.Base(o.filePath)
+
+	return strings.TrimSuffix(filename, path.Ext(filename))
+}
+
 func (o *defaultGameSettings) AddCategory(c string) {
 	current := o.Categories()
 
@@ -549,14 +1270,77 @@ func (o *defaultGameSettings) Categories() []string {
 	return strings.Split(data, listSeparator)
 }
 
+// HasCategories returns true if the game's categories are set in
+// its settings.
+func (o *defaultGameSettings) HasCategories() bool {
+	return len(o.config.KeyValue(none, gameCategories)) > 0
+}
+
+func (o *defaultGameSettings) SetAllowedSteamUsers(users []string) {
+	o.config.AddOrUpdateKeyValue(none, gameAllowedUsers, strings.Join(users, listSeparator))
+}
+
+func (o *defaultGameSettings) AllowedSteamUsers() []string {
+	return splitList(o.config.KeyValue(none, gameAllowedUsers))
+}
+
+func (o *defaultGameSettings) SetDeniedSteamUsers(users []string) {
+	o.config.AddOrUpdateKeyValue(none, gameDeniedUsers, strings.Join(users, listSeparator))
+}
+
+func (o *defaultGameSettings) DeniedSteamUsers() []string {
+	return splitList(o.config.KeyValue(none, gameDeniedUsers))
+}
+
+func (o *defaultGameSettings) SetRetroArchCore(coreName string) {
+	o.config.AddOrUpdateKeyValue(none, gameRetroArchCore, coreName)
+}
+
+// RetroArchCore returns the name of the RetroArch core that runs the game.
+// It is empty if the game uses its game collection's core.
+func (o *defaultGameSettings) RetroArchCore() string {
+	return o.config.KeyValue(none, gameRetroArchCore)
+}
+
 type KnownGamesSettings interface {
 	SaveableSettings
 	GameDirPathsToGameNames() map[string]string
-	AddUniqueGameOnly(game GameSettings, gameDirPath string) bool
+	GameName(gameDirPath string) (gameName string, ok bool)
+	ShortcutIds(gameDirPath string) (appId string, legacyGridId string, ok bool)
+	GameHashes(gameDirPath string) (hashes GameHashes, ok bool)
+	DuplicateOf(gameDirPath string, sha1 string) (otherGameDirPath string, isDuplicate bool)
+	IsUniqueGame(appId string, gameDirPath string) bool
+	AddGame(gameDirPath string, game KnownGame)
 	Disown(gameDirPath string) (gameName string, ok bool)
 	DisownNonExistingGames() (gameDirPathsToGameNames map[string]string)
 }
 
+// GameHashes are the hashes of a game's primary file.
+type GameHashes struct {
+	CRC32 string
+	SHA1  string
+
+	// Fingerprint identifies the version of the file that was hashed,
+	// such as its path, size, and modification time. The hashes only
+	// need to be computed again if the fingerprint changes.
+	Fingerprint string
+}
+
+// KnownGame is what is known about a game once its shortcut has been
+// created or updated.
+type KnownGame struct {
+	Name string
+
+	// AppId and LegacyGridId are the IDs that Steam derives from
+	// the game's shortcut.
+	AppId        string
+	LegacyGridId string
+
+	// Hashes are the hashes of the game's primary file. They are not
+	// changed if the SHA1 is empty.
+	Hashes GameHashes
+}
+
 type defaultKnownGamesSettings struct {
 	mutex    *sync.Mutex
 	config   configFile
@@ -599,29 +1383,118 @@ func (o *defaultKnownGamesSettings) GameDirPathsToGameNames() map[string]string
 	return o.config.SectionKeysToValues(none)
 }
 
-func (o *defaultKnownGamesSettings) AddUniqueGameOnly(game GameSettings, dirPath string) bool {
+func (o *defaultKnownGamesSettings) GameName(dirPath string) (string, bool) {
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
 
-	var count int
+	if !o.config.HasKey(none, key(dirPath)) {
+		return "", false
+	}
 
-	for _, gameName := range o.config.SectionValues(none) {
-		if gameName == game.Name() {
-			count++
+	return o.config.KeyValue(none, key(dirPath)), true
+}
 
-			// TODO: Why did I do this again?
-			if count > 1 {
-				return false
-			}
+func (o *defaultKnownGamesSettings) ShortcutIds(dirPath string) (string, string, bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if !o.config.HasKey(knownAppIds, key(dirPath)) {
+		return "", "", false
+	}
+
+	return o.config.KeyValue(knownAppIds, key(dirPath)), o.config.KeyValue(knownGridIds, key(dirPath)), true
+}
+
+func (o *defaultKnownGamesSettings) GameHashes(dirPath string) (GameHashes, bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.gameHashesUnsafe(dirPath)
+}
+
+func (o *defaultKnownGamesSettings) gameHashesUnsafe(dirPath string) (GameHashes, bool) {
+	if !o.config.HasKey(knownSha1s, key(dirPath)) {
+		return GameHashes{}, false
+	}
+
+	return GameHashes{
+		CRC32:       o.config.KeyValue(knownCrc32s, key(dirPath)),
+		SHA1:        o.config.KeyValue(knownSha1s, key(dirPath)),
+		Fingerprint: o.config.KeyValue(knownFiles, key(dirPath)),
+	}, true
+}
+
+// DuplicateOf returns the directory of another known game whose primary
+// file has the same SHA1 as the game's primary file.
+func (o *defaultKnownGamesSettings) DuplicateOf(dirPath string, sha1 string) (string, bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if len(sha1) == 0 {
+		return "", false
+	}
+
+	for otherDirPath, otherSha1 := range o.config.SectionKeysToValues(knownSha1s) {
+		if otherDirPath != dirPath && strings.EqualFold(otherSha1, sha1) {
+			return otherDirPath, true
 		}
 	}
 
-	o.config.AddOrUpdateKeyValue(none, key(dirPath), game.Name())
-	o.saveUnsafe()
+	return "", false
+}
+
+// IsUniqueGame returns true if no other known game has a shortcut with
+// the same app ID. Steam derives the app ID from the shortcut's name and
+// executable, and names the shortcut's artwork after it, so games that
+// share both would overwrite each other's artwork.
+func (o *defaultKnownGamesSettings) IsUniqueGame(appId string, dirPath string) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	for otherDirPath, otherAppId := range o.config.SectionKeysToValues(knownAppIds) {
+		if otherDirPath != dirPath && otherAppId == appId {
+			return false
+		}
+	}
 
 	return true
 }
 
+// AddGame adds the game to the known games, or updates it if it is
+// already known. The known games are saved once, and only if they
+// were changed.
+func (o *defaultKnownGamesSettings) AddGame(dirPath string, game KnownGame) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	values := map[section]string{
+		none:         game.Name,
+		knownAppIds:  game.AppId,
+		knownGridIds: game.LegacyGridId,
+	}
+
+	if len(game.Hashes.SHA1) > 0 {
+		values[knownCrc32s] = game.Hashes.CRC32
+		values[knownSha1s] = game.Hashes.SHA1
+		values[knownFiles] = game.Hashes.Fingerprint
+	}
+
+	changed := false
+
+	for s, value := range values {
+		if o.config.HasKey(s, key(dirPath)) && o.config.KeyValue(s, key(dirPath)) == value {
+			continue
+		}
+
+		o.config.AddOrUpdateKeyValue(s, key(dirPath), value)
+		changed = true
+	}
+
+	if changed {
+		o.saveUnsafe()
+	}
+}
+
 func (o *defaultKnownGamesSettings) Disown(dirPath string) (string, bool) {
 	o.mutex.Lock()
 	defer o.mutex.Unlock()
@@ -654,6 +1527,11 @@ func (o *defaultKnownGamesSettings) disownUnsafe(dirPath string) (string, bool)
 	if o.config.HasKey(none, key(dirPath)) {
 		gameName := o.config.KeyValue(none, key(dirPath))
 		o.config.DeleteKey(none, key(dirPath))
+		o.config.DeleteKey(knownAppIds, key(dirPath))
+		o.config.DeleteKey(knownGridIds, key(dirPath))
+		o.config.DeleteKey(knownCrc32s, key(dirPath))
+		o.config.DeleteKey(knownSha1s, key(dirPath))
+		o.config.DeleteKey(knownFiles, key(dirPath))
 		o.saveUnsafe()
 
 		return gameName, true
@@ -662,24 +1540,17 @@ func (o *defaultKnownGamesSettings) disownUnsafe(dirPath string) (string, bool)
 	return "", false
 }
 
+// saveUnsafe replaces the known games file, so that it is never left
+// partially written if the application stops while saving it.
 func (o *defaultKnownGamesSettings) saveUnsafe() error {
-	f, err := os.OpenFile(o.filePath, os.O_WRONLY|os.O_CREATE, defaultFileMode)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	err = f.Truncate(0)
-	if err != nil {
-		return err
-	}
+	buffer := bytes.NewBuffer(nil)
 
-	err = o.config.Save(f)
+	err := o.config.Save(buffer)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return atomicfile.WriteFile(o.filePath, buffer.Bytes(), defaultFileMode)
 }
 
 func NewAppSettings() AppSettings {
@@ -721,6 +1592,22 @@ func NewGameSettings(dirPath string) GameSettings {
 	return s
 }
 
+// NewGameFileSettings returns the settings of a game that consists of
+// a single file, such as a game in a FlatLayout game collection. The
+// game is named after the file, and its images are found next to the
+// file by their suffixes (for example, 'my-game-grid.png').
+func NewGameFileSettings(filePath string) GameSettings {
+	s := &defaultGameSettings{
+		dirPath:  path.Dir(filePath),
+		filePath: filePath,
+		config:   newEmptyIniFile(),
+	}
+
+	s.ResetToDefaults()
+
+	return s
+}
+
 func LoadOrCreateKnownGamesSettings(parentDirPath string) (KnownGamesSettings, bool) {
 	s := &defaultKnownGamesSettings{
 		config: newEmptyIniFile(),
@@ -754,7 +1641,7 @@ func LoadGameSettings(filePath string, launcher Launcher) (GameSettings, error)
 		dirPath: path.Dir(filePath),
 	}
 
-	_, exeExists := d.ExeFullPath(launcher)
+	_, exeExists := d.ExeFullPath(launcher.WithRetroArchCore(d.RetroArchCore()))
 	if !exeExists {
 		return &defaultGameSettings{}, errors.New("The game config's executable path does not exist")
 	}
@@ -762,6 +1649,40 @@ func LoadGameSettings(filePath string, launcher Launcher) (GameSettings, error)
 	return d, nil
 }
 
+// splitList splits a list value into its trimmed, non-empty elements.
+func splitList(data string) []string {
+	var elements []string
+
+	for _, element := range strings.Split(data, listSeparator) {
+		element = strings.TrimSpace(element)
+		if len(element) > 0 {
+			elements = append(elements, element)
+		}
+	}
+
+	return elements
+}
+
+func joinLists(lists ...[]string) []string {
+	var joined []string
+
+	for _, l := range lists {
+		joined = append(joined, l...)
+	}
+
+	return joined
+}
+
+func prefixAll(prefix string, l []string) []string {
+	prefixed := make([]string, len(l))
+
+	for i := range l {
+		prefixed[i] = prefix + l[i]
+	}
+
+	return prefixed
+}
+
 func existingFilePath(dirPath string, suffixes []string) (string, bool) {
 	matchFunc := func(filename string) bool {
 		for i := range suffixes {
@@ -781,6 +1702,30 @@ func existingFilePath(dirPath string, suffixes []string) (string, bool) {
 	return "", false
 }
 
+// existingSiblingFilePath returns the path to a file in the directory
+// that is named baseName followed by one of the suffixes.
+func existingSiblingFilePath(dirPath string, baseName string, suffixes []string) (string, bool) {
+	for i := range suffixes {
+		filePath := path.Join(dirPath, baseName + suffixes[i])
+
+		info, statErr := os.Stat(filePath)
+		if statErr == nil && !info.IsDir() {
+			return filePath, true
+		}
+	}
+
+	return "", false
+}
+
+// isInDir returns true if p is inside of the directory.
+func isInDir(p string, dirPath string) bool {
+	if len(p) <= len(dirPath) || !strings.HasPrefix(p, dirPath) {
+		return false
+	}
+
+	return p[len(dirPath)] == '/' || p[len(dirPath)] == '\\'
+}
+
 func dirContainsFile(dirPath string, fileNameMatchFunc func(string) bool) (string, bool) {
 	dirInfos, err := ioutil.ReadDir(dirPath)
 	if err != nil {
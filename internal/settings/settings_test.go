@@ -16,9 +16,8 @@ func TestNewAppSettings(t *testing.T) {
 	}
 
 	exp := `[settings]
-auto_start = true
 
-[watch_paths]
+[game_collections]
 
 `
 	result := b.String()
@@ -32,10 +31,15 @@ func TestAppSettingsManipulateValues(t *testing.T) {
 	i := NewAppSettings()
 
 	testPath := "/path/to/junk"
-	i.AddWatchPath(testPath)
+	i.AddGameCollection(testPath, "dolphin")
 
-	if !i.HasWatchPath(testPath) {
-		t.Error("Missing watch path -", testPath)
+	launcherName, ok := i.HasGameCollection(testPath)
+	if !ok {
+		t.Error("Missing game collection -", testPath)
+	}
+
+	if launcherName != "dolphin" {
+		t.Error("Launcher name was", launcherName)
 	}
 
 	b := bytes.NewBuffer([]byte{})
@@ -46,22 +50,22 @@ func TestAppSettingsManipulateValues(t *testing.T) {
 	}
 
 	exp := `[settings]
-auto_start = true
 
-[watch_paths]
+[game_collections]
 `
 
-	expWithPath := exp + testPath + "\n"
+	expWithPath := exp + testPath + " = dolphin\n\n"
 	result := b.String()
 
 	if result != expWithPath {
 		t.Error("Result was", result)
 	}
 
-	i.RemoveWatchPath(testPath)
+	i.RemoveGameCollection(testPath)
 
-	if i.HasWatchPath(testPath) {
-		t.Error("Watch path is still present")
+	_, ok = i.HasGameCollection(testPath)
+	if ok {
+		t.Error("Game collection is still present")
 	}
 
 	b.Reset()
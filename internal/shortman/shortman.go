@@ -2,16 +2,25 @@ package shortman
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 
+	"github.com/stephen-fox/grundy/internal/metadata"
 	"github.com/stephen-fox/grundy/internal/results"
 	"github.com/stephen-fox/grundy/internal/settings"
 	"github.com/stephen-fox/grundy/internal/steamw"
 )
 
+const (
+	playlistExtension = ".m3u"
+	playlistFileMode  = 0644
+)
+
 type ShortcutManager interface {
+	UpdateAll(steamDataInfo steamw.DataInfo) []results.Result
 	RefreshAll(steamDataInfo steamw.DataInfo) []results.Result
 	Update(gamePaths []string, isDirs bool, steamDataInfo steamw.DataInfo) []results.Result
 	Delete(gamePaths []string, isDirs bool, steamDataInfo steamw.DataInfo) []results.Result
@@ -19,147 +28,811 @@ type ShortcutManager interface {
 
 type defaultShortcutManager struct {
 	config Config
+
+	// launchers are the launchers used by the current operation, by
+	// name. Each launcher discovers its RetroArch cores once, so they
+	// are reused rather than loaded for each game.
+	launchers map[string]settings.Launcher
 }
 
-func (o *defaultShortcutManager) RefreshAll(steamDataInfo steamw.DataInfo) []results.Result {
-	var deletedDirPaths []string
-	var existingDirPaths []string
+// UpdateAll creates or updates shortcuts for every game in every
+// configured game collection, and deletes shortcuts for known games
+// that no longer exist.
+func (o *defaultShortcutManager) UpdateAll(steamDataInfo steamw.DataInfo) []results.Result {
+	o.launchers = make(map[string]settings.Launcher)
 
-	for dirPath := range o.config.KnownGames.GameDirPathsToGameNames() {
-		info, statErr := os.Stat(dirPath)
-		if statErr != nil {
-			deletedDirPaths = append(deletedDirPaths, dirPath)
-		} else if info.IsDir() {
-			existingDirPaths = append(existingDirPaths, dirPath)
-		} else {
-			deletedDirPaths = append(deletedDirPaths, dirPath)
+	var r []results.Result
+	var gameDirPaths []string
+
+	_, deletedPaths := o.knownGames()
+
+	for collectionDirPath := range o.config.App.GameCollectionsPathsToLauncherNames() {
+		collectionGamePaths, err := o.collectionGamePaths(collectionDirPath)
+		if err != nil {
+			r = append(r, results.NewUpdateShortcutFailed(collectionDirPath,
+				"failed to read game collection - " + err.Error()))
+			continue
 		}
+
+		gameDirPaths = append(gameDirPaths, collectionGamePaths...)
 	}
 
+	batch := steamw.NewBatch()
+
+	r = append(r, o.update(gameDirPaths, true, steamDataInfo, batch)...)
+
+	r = append(r, o.delete(deletedPaths, true, steamDataInfo, batch)...)
+
+	return append(r, o.flush(batch, steamDataInfo)...)
+}
+
+func (o *defaultShortcutManager) RefreshAll(steamDataInfo steamw.DataInfo) []results.Result {
+	o.launchers = make(map[string]settings.Launcher)
+
+	existingDirPaths, deletedPaths := o.knownGames()
+
 	var r []results.Result
 
-	r = append(r, o.Update(existingDirPaths, true, steamDataInfo)...)
+	batch := steamw.NewBatch()
 
-	r = append(r, o.Delete(deletedDirPaths, true, steamDataInfo)...)
+	r = append(r, o.update(existingDirPaths, true, steamDataInfo, batch)...)
 
-	return r
+	r = append(r, o.delete(deletedPaths, true, steamDataInfo, batch)...)
+
+	return append(r, o.flush(batch, steamDataInfo)...)
 }
 
 func (o *defaultShortcutManager) Update(gamePaths []string, isDirs bool, dataInfo steamw.DataInfo) []results.Result {
+	o.launchers = make(map[string]settings.Launcher)
+
+	batch := steamw.NewBatch()
+
+	r := o.update(gamePaths, isDirs, dataInfo, batch)
+
+	return append(r, o.flush(batch, dataInfo)...)
+}
+
+// update queues changes to the games' shortcuts in the batch. Results
+// for games that could not be queued are returned.
+func (o *defaultShortcutManager) update(gamePaths []string, isDirs bool, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
 	var r []results.Result
 
-	for _, gameDir := range gamePaths {
-		if strings.HasPrefix(gameDir, o.config.IgnorePathPrefix) {
+	for _, p := range gamePaths {
+		if strings.HasPrefix(p, o.config.IgnorePathPrefix) {
 			continue
 		}
 
+		gameDirs := []string{p}
 		if !isDirs {
-			gameDir = path.Dir(gameDir)
+			gameDirs = o.filesGamePaths(p)
+		}
+
+		for _, gameDir := range gameDirs {
+			r = append(r, results.WithGameDirPath(o.updateGame(gameDir, dataInfo, batch), gameDir)...)
 		}
+	}
+
+	return r
+}
+
+// filesGamePaths returns the paths of the games that a file belongs to.
+// In a settings.FlatLayout game collection, a game file is a game, and
+// an image belongs to the game files that share its base name. In other
+// game collections, a file belongs to the game directory containing it.
+func (o *defaultShortcutManager) filesGamePaths(filePath string) []string {
+	collectionDirPath, launcherName, _ := o.config.App.GameCollectionOf(filePath)
 
-		collectionName := path.Dir(gameDir)
+	if o.config.App.GameCollectionLayout(collectionDirPath) != settings.FlatLayout {
+		return []string{path.Dir(filePath)}
+	}
 
-		launcherName, hasGameCollection := o.config.App.HasGameCollection(collectionName)
-		if !hasGameCollection {
-			r = append(r, results.NewUpdateShortcutSkipped(gameDir,
-				"game collection '" + collectionName + "' does not exist"))
+	for _, suffix := range settings.GameImageSuffixes {
+		if !strings.HasSuffix(filePath, suffix) {
 			continue
 		}
 
-		launcher, hasLauncher := o.config.Launchers.Has(launcherName)
+		launcher, hasLauncher := o.collectionLauncher(collectionDirPath, launcherName)
 		if !hasLauncher {
-			r = append(r, results.NewUpdateShortcutSkipped(gameDir,
-				"the specified launcher does not exist in the launchers settings - '" +
-				launcherName + "'"))
-			continue
+			return nil
 		}
 
-		game := settings.NewGameSettings(gameDir)
-		var err error
-		if strings.HasSuffix(gameDir, settings.FileExtension) {
-			game, err = settings.LoadGameSettings(gameDir, launcher)
-		} else {
-			exeFilePath, exeExists := game.ExeFullPath(launcher)
-			if !exeExists {
-				err = errors.New("the game's executable does not exist at '" + exeFilePath + "'")
+		baseName := strings.TrimSuffix(path.Base(filePath), suffix)
+
+		var matching []string
+
+		for _, gameFilePath := range settings.NewGameSettings(path.Dir(filePath)).ExeFullPaths(launcher) {
+			filename := path.Base(gameFilePath)
+			if strings.TrimSuffix(filename, path.Ext(filename)) == baseName {
+				matching = append(matching, gameFilePath)
 			}
 		}
-		if err != nil {
-			r = append(r, results.NewUpdateShortcutFailed(gameDir, err.Error()))
+
+		return matching
+	}
+
+	return []string{filePath}
+}
+
+// collectionGamePaths returns the paths of the games in a game
+// collection. Games may be inside of grouping directories, up to
+// the game collection's depth. Games that belong to a game
+// collection inside of this one are excluded.
+func (o *defaultShortcutManager) collectionGamePaths(collectionDirPath string) ([]string, error) {
+	gamePaths, err := o.allCollectionGamePaths(collectionDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []string
+
+	for _, p := range gamePaths {
+		owner, _, _ := o.config.App.GameCollectionOf(p)
+		_, isCollection := o.config.App.HasGameCollection(p)
+		if owner == collectionDirPath && !isCollection {
+			owned = append(owned, p)
+		}
+	}
+
+	return owned, nil
+}
+
+// collectionLauncher returns the game collection's launcher. A RetroArch
+// launcher runs the collection's games with the collection's core.
+func (o *defaultShortcutManager) collectionLauncher(collectionDirPath string, launcherName string) (settings.Launcher, bool) {
+	launcher, hasLauncher := o.launchers[launcherName]
+	if !hasLauncher {
+		launcher, hasLauncher = o.config.Launchers.Has(launcherName)
+		if !hasLauncher {
+			return launcher, false
+		}
+
+		if o.launchers != nil {
+			o.launchers[launcherName] = launcher
+		}
+	}
+
+	return launcher.WithRetroArchCore(o.config.App.GameCollectionRetroArchCore(collectionDirPath)), true
+}
+
+func (o *defaultShortcutManager) allCollectionGamePaths(collectionDirPath string) ([]string, error) {
+	launcherName, _ := o.config.App.HasGameCollection(collectionDirPath)
+	depth := o.config.App.GameCollectionDepth(collectionDirPath)
+
+	launcher, hasLauncher := o.collectionLauncher(collectionDirPath, launcherName)
+
+	if o.config.App.GameCollectionLayout(collectionDirPath) == settings.FlatLayout {
+		if !hasLauncher {
+			return nil, errors.New("the specified launcher does not exist in the launchers settings - '" +
+				launcherName + "'")
+		}
+
+		return gameFilePaths(collectionDirPath, launcher, depth)
+	}
+
+	// Grouping directories cannot be told apart from games
+	// without knowing which files the launcher runs.
+	if !hasLauncher {
+		depth = 0
+	}
+
+	return gameDirPaths(collectionDirPath, launcher, depth)
+}
+
+// gameDirPaths returns the subdirectories of a directory that are games.
+// Subdirectories that are not games are searched for games if depth is
+// greater than zero.
+func gameDirPaths(dirPath string, launcher settings.Launcher, depth int) ([]string, error) {
+	infos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var gameDirs []string
+
+	for _, info := range infos {
+		if !info.IsDir() {
 			continue
 		}
 
-		// TODO: Is this a good idea? Can we be certain that the shortcut
-		//  was not removed by someone/thing else besides us?
-		added := o.config.KnownGames.AddUniqueGameOnly(game, gameDir)
-		if !added {
-			r = append(r, results.NewUpdateShortcutSkipped(gameDir, "the game already exists"))
+		subDirPath := path.Join(dirPath, info.Name())
+
+		if depth > 0 && !isGameDir(subDirPath, launcher) {
+			nested, err := gameDirPaths(subDirPath, launcher, depth - 1)
+			if err == nil {
+				gameDirs = append(gameDirs, nested...)
+			}
 			continue
 		}
 
-		var warnings []string
+		gameDirs = append(gameDirs, subDirPath)
+	}
+
+	return gameDirs, nil
+}
+
+// isGameDir returns true if the directory contains game settings or
+// a file that the launcher can run.
+func isGameDir(dirPath string, launcher settings.Launcher) bool {
+	game := settings.NewGameSettings(dirPath)
+
+	if _, statErr := os.Stat(path.Join(dirPath, game.Filename(""))); statErr == nil {
+		return true
+	}
+
+	_, exeExists := game.ExeFullPath(launcher)
 
-		icon := game.IconPath()
-		if !icon.WasDynamicallySelected() && !icon.FileExists() {
-			r = append(r, results.NewUpdateShortcutFailed(gameDir,
-				"manual icon does not exist at - '" +
-				icon.FilePath() + "'"))
+	return exeExists
+}
+
+// gameFilePaths returns the paths of the files in a directory that the
+// launcher can run. Subdirectories are also searched if depth is greater
+// than zero.
+func gameFilePaths(dirPath string, launcher settings.Launcher, depth int) ([]string, error) {
+	filePaths := settings.NewGameSettings(dirPath).ExeFullPaths(launcher)
+
+	if depth == 0 {
+		return filePaths, nil
+	}
+
+	infos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		if !info.IsDir() {
 			continue
-		} else if icon.WasDynamicallySelected() && !icon.FileExists() {
-			warnings = append(warnings, "no icon was provided")
 		}
 
-		gridImage := game.GridImagePath()
-		if !gridImage.WasDynamicallySelected() && !gridImage.FileExists() {
-			r = append(r, results.NewUpdateShortcutFailed(gameDir,
-				"manual grid image does not exist at - '" +
-				gridImage.FilePath() + "'"))
+		nested, err := gameFilePaths(path.Join(dirPath, info.Name()), launcher, depth - 1)
+		if err == nil {
+			filePaths = append(filePaths, nested...)
+		}
+	}
+
+	return filePaths, nil
+}
+
+// groupNames returns the names of the grouping directories between
+// a game collection and a game.
+func groupNames(collectionDirPath string, gameDir string) []string {
+	rel := strings.TrimPrefix(path.Dir(gameDir), collectionDirPath)
+
+	return strings.FieldsFunc(rel, func(r rune) bool {
+		return r == '/' || r == '\\'
+	})
+}
+
+func (o *defaultShortcutManager) updateGame(gameDir string, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
+	var r []results.Result
+
+	collectionName, launcherName, hasGameCollection := o.config.App.GameCollectionOf(gameDir)
+	if !hasGameCollection {
+		r = append(r, results.NewUpdateShortcutSkipped(gameDir,
+			"game collection '" + path.Dir(gameDir) + "' does not exist"))
+		return r
+	}
+
+	if len(groupNames(collectionName, gameDir)) > o.config.App.GameCollectionDepth(collectionName) {
+		r = append(r, results.NewUpdateShortcutSkipped(gameDir,
+			"the game is nested too deeply in game collection '" + collectionName + "'"))
+		return r
+	}
+
+	launcher, hasLauncher := o.collectionLauncher(collectionName, launcherName)
+	if !hasLauncher {
+		r = append(r, results.NewUpdateShortcutSkipped(gameDir,
+			"the specified launcher does not exist in the launchers settings - '" +
+			launcherName + "'"))
+		return r
+	}
+
+	err := launcher.IsValid()
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(gameDir, "the launcher is invalid - " + err.Error()))
+		return r
+	}
+
+	game, err := o.loadGame(gameDir, collectionName, launcher)
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(gameDir, err.Error()))
+		return r
+	}
+
+	launcher = launcher.WithRetroArchCore(game.RetroArchCore())
+
+	shortcuts, err := o.gameShortcuts(gameDir, collectionName, game, launcher)
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(gameDir, err.Error()))
+		return r
+	}
+
+	current := make(map[string]bool)
+
+	for _, s := range shortcuts {
+		current[s.knownPath] = true
+	}
+
+	// Shortcuts for files that no longer exist, or that were made
+	// before the game collection's mode was changed, are deleted
+	// first so that they are not mistaken for duplicates.
+	for knownPath := range o.config.KnownGames.GameDirPathsToGameNames() {
+		if current[knownPath] || (knownPath != gameDir && path.Dir(knownPath) != gameDir) {
 			continue
-		} else if gridImage.WasDynamicallySelected() && !gridImage.FileExists() {
-			warnings = append(warnings, "no grid image was provided")
 		}
 
-		config := steamw.NewShortcutConfig{
-			Name:          game.Name(),
-			LaunchOptions: createLauncherArgs(game, launcher),
-			ExePath:       launcher.ExePath(),
-			IconPath:      icon.FilePath(),
-			GridImagePath: gridImage.FilePath(),
-			Tags:          game.Categories(),
-			Info:          dataInfo,
-			Warnings:      warnings,
+		r = append(r, o.deleteKnownGame(knownPath, gameDir, collectionName, launcher.ExePath(), dataInfo, batch)...)
+	}
+
+	for _, s := range shortcuts {
+		r = append(r, o.updateShortcut(s, collectionName, launcher, dataInfo, batch)...)
+	}
+
+	return r
+}
+
+// newGameSettings returns the default settings of a game. Games in a
+// settings.FlatLayout game collection are a single file.
+func (o *defaultShortcutManager) newGameSettings(gameDir string, collectionName string) settings.GameSettings {
+	if o.config.App.GameCollectionLayout(collectionName) == settings.FlatLayout {
+		return settings.NewGameFileSettings(gameDir)
+	}
+
+	return settings.NewGameSettings(gameDir)
+}
+
+// loadGame loads the game's settings, or creates default settings if
+// the game does not have a settings file.
+func (o *defaultShortcutManager) loadGame(gameDir string, collectionName string, launcher settings.Launcher) (settings.GameSettings, error) {
+	game := o.newGameSettings(gameDir, collectionName)
+
+	if o.config.App.GameCollectionLayout(collectionName) != settings.FlatLayout {
+		gameSettingsPath := path.Join(gameDir, game.Filename(""))
+		if _, statErr := os.Stat(gameSettingsPath); statErr == nil {
+			return settings.LoadGameSettings(gameSettingsPath, launcher)
+		}
+	}
+
+	exeFilePath, exeExists := game.ExeFullPath(launcher)
+	if !exeExists {
+		return game, errors.New("the game's executable does not exist at '" + exeFilePath + "'")
+	}
+
+	return game, nil
+}
+
+// gameShortcut is a shortcut for a game.
+type gameShortcut struct {
+	// knownPath identifies the shortcut in the known games. It is the
+	// game's directory, or the game file's path for games in a
+	// settings.PerFileMode or settings.FlatLayout game collection.
+	knownPath string
+
+	// gameDir is the path to the game's directory, or the game's file
+	// for games in a settings.FlatLayout game collection.
+	gameDir string
+
+	// exeFilePath is the path to the file that the launcher runs.
+	exeFilePath string
+
+	// primaryFilePath is the path to the file that identifies the game.
+	// It differs from exeFilePath when the launcher runs a playlist.
+	primaryFilePath string
+
+	// namePattern, if not empty, names the shortcut.
+	namePattern string
+}
+
+// gameShortcuts returns the shortcuts that should exist for a game,
+// according to its game collection's mode. The playlist of a game in
+// a settings.PlaylistMode collection is written if it has changed.
+// Games in a settings.FlatLayout collection always have one shortcut.
+func (o *defaultShortcutManager) gameShortcuts(gameDir string, collectionName string, game settings.GameSettings, launcher settings.Launcher) ([]gameShortcut, error) {
+	mode := o.config.App.GameCollectionMode(collectionName)
+	if o.config.App.GameCollectionLayout(collectionName) == settings.FlatLayout {
+		mode = settings.DirectoryMode
+	}
+
+	switch mode {
+	case settings.PerFileMode:
+		var shortcuts []gameShortcut
+
+		namePattern := o.config.App.GameCollectionNamePattern(collectionName)
+
+		for _, exeFilePath := range game.ExeFullPaths(launcher) {
+			shortcuts = append(shortcuts, gameShortcut{
+				knownPath:       exeFilePath,
+				gameDir:         gameDir,
+				exeFilePath:     exeFilePath,
+				primaryFilePath: exeFilePath,
+				namePattern:     namePattern,
+			})
+		}
+
+		return shortcuts, nil
+	case settings.PlaylistMode:
+		var discFilePaths []string
+
+		for _, exeFilePath := range game.ExeFullPaths(launcher) {
+			if !strings.EqualFold(path.Ext(exeFilePath), playlistExtension) {
+				discFilePaths = append(discFilePaths, exeFilePath)
+			}
+		}
+
+		if len(discFilePaths) > 1 {
+			playlistFilePath, err := o.writePlaylist(gameDir, discFilePaths)
+			if err != nil {
+				return nil, err
+			}
+
+			return []gameShortcut{
+				{
+					knownPath:       gameDir,
+					gameDir:         gameDir,
+					exeFilePath:     playlistFilePath,
+					primaryFilePath: discFilePaths[0],
+				},
+			}, nil
+		}
+	}
+
+	exeFilePath, _ := game.ExeFullPath(launcher)
+
+	return []gameShortcut{
+		{
+			knownPath:       gameDir,
+			gameDir:         gameDir,
+			exeFilePath:     exeFilePath,
+			primaryFilePath: exeFilePath,
+		},
+	}, nil
+}
+
+// writePlaylist writes an m3u playlist of the game's discs to the game's
+// directory, and returns the playlist's path. The playlist is only
+// written if its contents have changed, and is not written at all
+// when planning.
+func (o *defaultShortcutManager) writePlaylist(gameDir string, discFilePaths []string) (string, error) {
+	playlistFilePath := path.Join(gameDir, path.Base(gameDir) + playlistExtension)
+
+	var contents string
+
+	for _, discFilePath := range discFilePaths {
+		contents = contents + path.Base(discFilePath) + "\n"
+	}
+
+	existing, err := ioutil.ReadFile(playlistFilePath)
+	if (err == nil && string(existing) == contents) || o.config.PlanOnly {
+		return playlistFilePath, nil
+	}
+
+	err = ioutil.WriteFile(playlistFilePath, []byte(contents), playlistFileMode)
+	if err != nil {
+		return playlistFilePath, errors.New("failed to write playlist '" +
+			playlistFilePath + "' - " + err.Error())
+	}
+
+	return playlistFilePath, nil
+}
+
+// shortcutName returns the name of a shortcut made from a name pattern.
+func shortcutName(namePattern string, game settings.GameSettings, exeFilePath string) string {
+	filename := path.Base(exeFilePath)
+
+	return strings.NewReplacer(
+		settings.NamePatternName, game.Name(),
+		settings.NamePatternFile, strings.TrimSuffix(filename, path.Ext(filename)),
+	).Replace(namePattern)
+}
+
+func (o *defaultShortcutManager) updateShortcut(s gameShortcut, collectionName string, launcher settings.Launcher, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
+	var r []results.Result
+
+	// The game's settings are loaded for each of its shortcuts because
+	// the game's name and categories are changed below.
+	game, err := o.loadGame(s.gameDir, collectionName, launcher)
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath, err.Error()))
+		return r
+	}
+
+	var warnings []string
+
+	hashes, err := o.gameHashes(s.knownPath, s.primaryFilePath)
+	if err != nil {
+		warnings = append(warnings, "failed to hash the game's file - " + err.Error())
+	}
+
+	duplicateDirPath, isDuplicate := o.config.KnownGames.DuplicateOf(s.knownPath, hashes.SHA1)
+	if isDuplicate {
+		reason := "the game's file is identical to the file of the game at '" + duplicateDirPath + "'"
+
+		// The game may have had a shortcut before its file became a
+		// copy of another game's file. Only one shortcut is kept for
+		// the file, and it belongs to the game that was known first.
+		_, wasKnown := o.config.KnownGames.GameName(s.knownPath)
+		if wasKnown {
+			reason = reason + " - its shortcut was removed, and the shortcut of the game at '" +
+				duplicateDirPath + "' was kept"
+		}
+
+		r = append(r, results.NewUpdateShortcutDuplicate(s.knownPath, reason))
+
+		if wasKnown {
+			r = append(r, o.deleteKnownGame(s.knownPath, s.gameDir, collectionName,
+				launcher.ExePath(), dataInfo, batch)...)
+		}
+
+		return r
+	}
+
+	if o.config.Metadata != nil && (!game.HasName() || !game.HasCategories()) {
+		err := o.applyMetadata(game, metadata.Query{
+			FilePath: s.primaryFilePath,
+			CRC32:    hashes.CRC32,
+			SHA1:     hashes.SHA1,
+		})
+		if err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+
+	if o.config.App.GameCollectionGroupCategories(collectionName) {
+		for _, group := range groupNames(collectionName, s.gameDir) {
+			game.AddCategory(group)
 		}
+	}
+
+	if len(s.namePattern) > 0 {
+		game.SetName(shortcutName(s.namePattern, game, s.exeFilePath))
+	}
+
+	// The game's name may have changed since the last time we saw it.
+	// If so, the existing shortcut is renamed rather than orphaned.
+	previousName, _ := o.config.KnownGames.GameName(s.knownPath)
+	if previousName == game.Name() {
+		previousName = ""
+	}
+
+	icon := game.IconPath()
+	if !icon.WasDynamicallySelected() && !icon.FileExists() {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath,
+			"manual icon does not exist at - '" +
+			icon.FilePath() + "'"))
+		return r
+	} else if icon.WasDynamicallySelected() && !icon.FileExists() {
+		warnings = append(warnings, "no icon was provided")
+	}
+
+	gridImage := game.GridImagePath()
+	if !gridImage.WasDynamicallySelected() && !gridImage.FileExists() {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath,
+			"manual grid image does not exist at - '" +
+			gridImage.FilePath() + "'"))
+		return r
+	} else if gridImage.WasDynamicallySelected() && !gridImage.FileExists() {
+		warnings = append(warnings, "no grid image was provided")
+	}
+
+	portraitImage, err := artworkFilePath(game.PortraitImagePath())
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath, err.Error()))
+		return r
+	}
+
+	heroImage, err := artworkFilePath(game.HeroImagePath())
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath, err.Error()))
+		return r
+	}
 
-		r = append(r, steamw.CreateOrUpdateShortcut(config)...)
+	logoImage, err := artworkFilePath(game.LogoImagePath())
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath, err.Error()))
+		return r
 	}
 
+	launchOptions, err := createLauncherArgs(game, launcher, s.exeFilePath)
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed(s.knownPath, err.Error()))
+		return r
+	}
+
+	imageFit, err := steamw.ParseImageFit(o.config.App.ImageFit())
+	if err != nil {
+		warnings = append(warnings, "images will not be resized - " + err.Error())
+	}
+
+	config := steamw.NewShortcutConfig{
+		ShortcutId:        steamw.GameShortcutId(s.knownPath),
+		Name:              game.Name(),
+		PreviousName:      previousName,
+		LaunchOptions:     launchOptions,
+		ExePath:           launcher.ExePath(),
+		IconPath:          icon.FilePath(),
+		GridImagePath:     gridImage.FilePath(),
+		PortraitImagePath: portraitImage,
+		HeroImagePath:     heroImage,
+		LogoImagePath:     logoImage,
+		Tags:              game.Categories(),
+		GameDirPath:       s.gameDir,
+		UserFilters:       o.userFilters(collectionName, game),
+		Info:              dataInfo,
+		ImageFit:          imageFit,
+		Warnings:          warnings,
+	}
+
+	ids := steamw.NewShortcutIds(config)
+
+	if !o.config.KnownGames.IsUniqueGame(ids.AppIdString(), s.knownPath) {
+		r = append(r, results.NewUpdateShortcutSkipped(s.knownPath,
+			"another game named '" + game.Name() + "' already uses the same launcher"))
+		return r
+	}
+
+	if o.config.PlanOnly {
+		r = append(r, steamw.PlanCreateOrUpdateShortcut(config)...)
+		return r
+	}
+
+	batch.CreateOrUpdateShortcut(config)
+
+	// The game is only known once its shortcut has been queued,
+	// so that a game that failed to update is not mistaken for
+	// the owner of its name or file.
+	o.config.KnownGames.AddGame(s.knownPath, settings.KnownGame{
+		Name:         game.Name(),
+		AppId:        ids.AppIdString(),
+		LegacyGridId: ids.LegacyGridIdString(),
+		Hashes:       hashes,
+	})
+
 	return r
 }
 
-// TODO: Refactor this.
-func createLauncherArgs(game settings.GameSettings, launcher settings.Launcher) []string {
-	var options []string
+// applyMetadata fills in the game's name and categories using the
+// metadata provider, unless they are set in the game's settings.
+func (o *defaultShortcutManager) applyMetadata(game settings.GameSettings, query metadata.Query) error {
+	info, found, err := o.config.Metadata.Lookup(query)
+	if err != nil {
+		return errors.New("failed to look up game metadata - " + err.Error())
+	}
+
+	if !found {
+		return nil
+	}
+
+	if !game.HasName() && len(info.Title) > 0 {
+		game.SetName(info.Title)
+	}
+
+	if !game.HasCategories() && len(info.Tags()) > 0 {
+		game.SetCategories(info.Tags())
+	}
+
+	return nil
+}
+
+// gameHashes returns the hashes of the game's primary file. The hashes
+// stored in the known games are reused if the file has not changed.
+// No hashes are returned if the primary file is a directory or is
+// empty, as such files say nothing about the game.
+func (o *defaultShortcutManager) gameHashes(gameDir string, exeFilePath string) (settings.GameHashes, error) {
+	info, err := os.Stat(exeFilePath)
+	if err != nil {
+		return settings.GameHashes{}, err
+	}
+
+	if info.IsDir() || info.Size() == 0 {
+		return settings.GameHashes{}, nil
+	}
+
+	fingerprint := exeFilePath + "|" + strconv.FormatInt(info.Size(), 10) + "|" +
+		strconv.FormatInt(info.ModTime().UnixNano(), 10)
+
+	known, ok := o.config.KnownGames.GameHashes(gameDir)
+	if ok && known.Fingerprint == fingerprint {
+		return known, nil
+	}
+
+	crc, sha1, err := metadata.FileHashes(exeFilePath)
+	if err != nil {
+		return settings.GameHashes{}, err
+	}
+
+	return settings.GameHashes{
+		CRC32:       crc,
+		SHA1:        sha1,
+		Fingerprint: fingerprint,
+	}, nil
+}
+
+// artworkFilePath returns the path to an optional artwork image. An empty
+// string is returned if the game does not have the image. Unlike the grid
+// image, the absence of artwork is not worth a warning.
+func artworkFilePath(image settings.DynamicFilePath) (string, error) {
+	if !image.WasDynamicallySelected() && !image.FileExists() {
+		return "", errors.New("manual artwork image does not exist at - '" +
+			image.FilePath() + "'")
+	}
+
+	if !image.FileExists() {
+		return "", nil
+	}
+
+	return image.FilePath(), nil
+}
+
+// createLauncherArgs returns the launcher's arguments for the game. The
+// placeholders in the arguments are replaced, and the path to the
+// game's file is added to the end unless the arguments contain the
+// settings.GamePathPlaceholder. A RetroArch launcher's default arguments
+// include the core that was selected for the game.
+func createLauncherArgs(game settings.GameSettings, launcher settings.Launcher, exePath string) ([]string, error) {
+	var args []string
 
 	if game.ShouldOverrideLauncherArgs() {
-		options = append(options, game.LauncherOverrideArgs())
+		override, err := game.LauncherOverrideArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, override...)
 	} else {
-		if len(launcher.DefaultArgs()) > 0 {
-			options = append(options, launcher.DefaultArgs())
+		if _, hasCore := launcher.RetroArchCore(); launcher.Type() == settings.RetroArchLauncher && !hasCore {
+			return nil, errors.New("a RetroArch core must be assigned to the game or to its game collection")
 		}
 
-		if len(game.AdditionalLauncherArgs()) > 0 {
-			options = append(options, game.AdditionalLauncherArgs())
+		defaults, err := launcher.DefaultArgs()
+		if err != nil {
+			return nil, err
 		}
+
+		args = append(args, defaults...)
+
+		additional, err := game.AdditionalLauncherArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, additional...)
 	}
 
-	exePath, _ := game.ExeFullPath(launcher)
+	filename := path.Base(exePath)
 
-	options = append(options, "\"" + exePath + "\"")
+	values := settings.ArgsValues{
+		GamePath:     exePath,
+		GameDir:      path.Dir(exePath),
+		GameName:     game.Name(),
+		GameBasename: strings.TrimSuffix(filename, path.Ext(filename)),
+		LauncherDir:  launcher.ExeDirPath(),
+	}
+
+	options, err := settings.ExpandArgsTemplate(args, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if !settings.HasPlaceholder(args, settings.GamePathPlaceholder) {
+		options = append(options, exePath)
+	}
 
-	return options
+	return options, nil
 }
 
 func (o *defaultShortcutManager) Delete(gamePaths []string, isDirs bool, dataInfo steamw.DataInfo) []results.Result {
+	o.launchers = make(map[string]settings.Launcher)
+
+	batch := steamw.NewBatch()
+
+	r := o.delete(gamePaths, isDirs, dataInfo, batch)
+
+	return append(r, o.flush(batch, dataInfo)...)
+}
+
+// delete queues deletions of the games' shortcuts in the batch. Results
+// for games that could not be queued are returned.
+func (o *defaultShortcutManager) delete(gamePaths []string, isDirs bool, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
 	var r []results.Result
 
 	for _, p := range gamePaths {
@@ -167,49 +840,201 @@ func (o *defaultShortcutManager) Delete(gamePaths []string, isDirs bool, dataInf
 			continue
 		}
 
+		gameDirs := []string{p}
 		if !isDirs {
-			p = path.Dir(p)
-		}
-
-		var launcherExePath string
-
-		// Do not delete if there is an executable in the directory.
-		launcherName, hasCollection := o.config.App.HasGameCollection(p)
-		if hasCollection {
-			launcher, hasLauncher := o.config.Launchers.Has(launcherName)
-			if hasLauncher {
-				launcherExePath = launcher.ExePath()
-				game := settings.NewGameSettings(p)
-				exePath, exeExists := game.ExeFullPath(launcher)
-				if exeExists {
-					r = append(r, results.NewDeleteShortcutSkipped(game.Name(),
-						"a game executable still exists in its directory at '" + exePath + "'"))
-					continue
+			gameDirs = o.filesGamePaths(p)
+		}
+
+		for _, gameDir := range gameDirs {
+			r = append(r, results.WithGameDirPath(o.deleteGame(gameDir, dataInfo, batch), gameDir)...)
+		}
+	}
+
+	return r
+}
+
+func (o *defaultShortcutManager) deleteGame(p string, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
+	var r []results.Result
+
+	var launcherExePath string
+
+	gameDir := o.knownGameDirPath(p)
+
+	// Do not delete if there is an executable in the directory.
+	collectionName, launcherName, hasCollection := o.config.App.GameCollectionOf(gameDir)
+	if hasCollection {
+		launcher, hasLauncher := o.collectionLauncher(collectionName, launcherName)
+		if hasLauncher {
+			launcherExePath = launcher.ExePath()
+			game := o.newGameSettings(gameDir, collectionName)
+			exePath, exeExists := game.ExeFullPath(launcher)
+			if exeExists {
+				// The game's shortcuts depend on which of its files
+				// exist, so it is updated instead.
+				if o.config.App.GameCollectionMode(collectionName) != settings.DirectoryMode {
+					return o.updateGame(gameDir, dataInfo, batch)
 				}
+
+				r = append(r, results.NewDeleteShortcutSkipped(game.Name(),
+					"a game executable still exists in its directory at '" + exePath + "'"))
+				return r
 			}
 		}
+	}
 
-		gameName, ok := o.config.KnownGames.Disown(p)
-		if ok {
-			config := steamw.DeleteShortcutConfig{
-				GameName:            gameName,
-				Info:                dataInfo,
-				SkipGridImageDelete: len(launcherExePath) == 0,
-				LauncherExePath:     launcherExePath,
-			}
+	return o.deleteKnownGame(p, gameDir, collectionName, launcherExePath, dataInfo, batch)
+}
+
+// deleteKnownGame deletes the shortcut of a known game.
+func (o *defaultShortcutManager) deleteKnownGame(knownPath string, gameDir string, collectionName string, launcherExePath string, dataInfo steamw.DataInfo, batch *steamw.Batch) []results.Result {
+	var r []results.Result
+
+	var gameName string
+	var ok bool
+	if o.config.PlanOnly {
+		gameName, ok = o.config.KnownGames.GameDirPathsToGameNames()[knownPath]
+	} else {
+		gameName, ok = o.config.KnownGames.Disown(knownPath)
+	}
+	if ok {
+		config := steamw.DeleteShortcutConfig{
+			ShortcutId:      steamw.GameShortcutId(knownPath),
+			GameName:        gameName,
+			GameDirPath:     gameDir,
+			Info:            dataInfo,
+			LauncherExePath: launcherExePath,
+			UserFilters:     o.userFilters(collectionName, nil),
+		}
 
-			r = append(r, steamw.DeleteShortcut(config)...)
+		if o.config.PlanOnly {
+			r = append(r, steamw.PlanDeleteShortcut(config)...)
+		} else {
+			batch.DeleteShortcut(config)
 		}
 	}
 
 	return r
 }
 
+// knownGameDirPath returns the directory of the game that a known game
+// path belongs to. Games in a settings.PerFileMode game collection are
+// known by their files rather than their directories. Games in a
+// settings.FlatLayout game collection are their files.
+func (o *defaultShortcutManager) knownGameDirPath(knownPath string) string {
+	collectionDirPath, launcherName, hasCollection := o.config.App.GameCollectionOf(knownPath)
+	if !hasCollection || o.config.App.GameCollectionLayout(collectionDirPath) == settings.FlatLayout {
+		return knownPath
+	}
+
+	info, statErr := os.Stat(knownPath)
+	if statErr == nil {
+		if info.IsDir() {
+			return knownPath
+		}
+
+		return path.Dir(knownPath)
+	}
+
+	// The known game no longer exists, so the only clue as to whether
+	// it was a file or a directory is its name.
+	if path.Dir(knownPath) == collectionDirPath {
+		return knownPath
+	}
+
+	launcher, hasLauncher := o.collectionLauncher(collectionDirPath, launcherName)
+	if hasLauncher {
+		matcher, err := launcher.GameFileMatcher()
+		if err == nil {
+			if _, isGameFile := matcher.Match(path.Base(knownPath)); isGameFile {
+				return path.Dir(knownPath)
+			}
+		}
+	}
+
+	return knownPath
+}
+
+// knownGames returns the directories (or, in settings.FlatLayout game
+// collections, the files) of the known games that still exist, and the
+// paths of the known games that do not. A game known by one of its
+// files is only considered deleted when its directory no longer
+// exists, as updating the game deletes the shortcuts of its
+// missing files.
+func (o *defaultShortcutManager) knownGames() ([]string, []string) {
+	var existingDirPaths []string
+	var deletedPaths []string
+
+	seen := make(map[string]bool)
+
+	for knownPath := range o.config.KnownGames.GameDirPathsToGameNames() {
+		gameDir := o.knownGameDirPath(knownPath)
+
+		collectionDirPath, _, _ := o.config.App.GameCollectionOf(gameDir)
+		isFlat := o.config.App.GameCollectionLayout(collectionDirPath) == settings.FlatLayout
+
+		info, statErr := os.Stat(gameDir)
+		if statErr != nil || info.IsDir() == isFlat {
+			deletedPaths = append(deletedPaths, knownPath)
+		} else if !seen[gameDir] {
+			seen[gameDir] = true
+			existingDirPaths = append(existingDirPaths, gameDir)
+		}
+	}
+
+	return existingDirPaths, deletedPaths
+}
+
+// flush saves the changes in the batch, deferring them if Steam
+// is running and pending changes are enabled.
+func (o *defaultShortcutManager) flush(batch *steamw.Batch, dataInfo steamw.DataInfo) []results.Result {
+	if o.config.PendingChanges == nil {
+		return batch.Flush()
+	}
+
+	return batch.FlushOrDefer(o.config.PendingChanges, dataInfo)
+}
+
+// userFilters returns the Steam user filters for a game collection and,
+// optionally, one of its games.
+func (o *defaultShortcutManager) userFilters(collectionDirPath string, game settings.GameSettings) []steamw.UserFilter {
+	allowed, denied := o.config.App.GameCollectionSteamUsers(collectionDirPath)
+
+	filters := []steamw.UserFilter{
+		{
+			Allowed: allowed,
+			Denied:  denied,
+		},
+	}
+
+	if game != nil {
+		filters = append(filters, steamw.UserFilter{
+			Allowed: game.AllowedSteamUsers(),
+			Denied:  game.DeniedSteamUsers(),
+		})
+	}
+
+	return filters
+}
+
 type Config struct {
 	App              settings.AppSettings
 	KnownGames       settings.KnownGamesSettings
 	Launchers        settings.LaunchersSettings
 	IgnorePathPrefix string
+
+	// PlanOnly reports the changes that would be made to each Steam
+	// user's shortcuts without modifying the shortcuts, grid images,
+	// or the known games.
+	PlanOnly bool
+
+	// PendingChanges, if not nil, receives shortcut changes that are
+	// made while Steam is running. The changes are made once Steam
+	// is no longer running.
+	PendingChanges *steamw.PendingChanges
+
+	// Metadata, if not nil, provides the names and categories of
+	// games whose settings do not specify them.
+	Metadata metadata.Provider
 }
 
 func NewShortcutManager(config Config) ShortcutManager {
@@ -0,0 +1,309 @@
+package shortman
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/grundy/internal/settings"
+	"github.com/stephen-fox/grundy/internal/steamw"
+	"github.com/stephen-fox/steamutil/locations"
+	"github.com/stephen-fox/steamutil/shortcuts"
+)
+
+func TestUpdateRemovesShortcutOfGameThatBecameDuplicate(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-shortman-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	info, shortcutsFilePath := createTestSteamData(t, rootDirPath)
+
+	exePath := path.Join(rootDirPath, "emulator")
+	writeTestFile(t, exePath, "")
+
+	collectionDirPath := path.Join(rootDirPath, "games")
+	originalDirPath := path.Join(collectionDirPath, "Pikmin")
+	copyDirPath := path.Join(collectionDirPath, "Pikmin Copy")
+
+	writeTestFile(t, path.Join(originalDirPath, "game.iso"), "pikmin")
+	writeTestFile(t, path.Join(copyDirPath, "game.iso"), "something else")
+
+	app := settings.NewAppSettings()
+	app.AddGameCollection(collectionDirPath, "emulator")
+
+	launchers := settings.NewLaunchersSettings()
+	launchers.AddOrUpdate(newTestLauncher("emulator", exePath))
+
+	knownGames, _ := settings.LoadOrCreateKnownGamesSettings(rootDirPath)
+
+	manager := NewShortcutManager(Config{
+		App:              app,
+		KnownGames:       knownGames,
+		Launchers:        launchers,
+		IgnorePathPrefix: path.Join(rootDirPath, "settings"),
+	})
+
+	for _, result := range manager.Update([]string{originalDirPath, copyDirPath}, true, info) {
+		if result.Outcome() == results.Failed || result.Outcome() == results.Duplicate {
+			t.Fatal(result.PrintableResult())
+		}
+	}
+
+	if names := testShortcutNames(t, shortcutsFilePath); len(names) != 2 {
+		t.Fatal("Expected shortcuts for both games - got", names)
+	}
+
+	// The copy's file now has the same contents as the original's file.
+	writeTestFile(t, path.Join(copyDirPath, "game.iso"), "pikmin")
+
+	var wasDuplicate bool
+
+	for _, result := range manager.Update([]string{copyDirPath}, true, info) {
+		if result.Outcome() == results.Failed {
+			t.Fatal(result.PrintableResult())
+		}
+
+		if result.Outcome() == results.Duplicate {
+			wasDuplicate = true
+		}
+	}
+
+	if !wasDuplicate {
+		t.Fatal("Expected the copy to be reported as a duplicate")
+	}
+
+	if _, isKnown := knownGames.GameName(copyDirPath); isKnown {
+		t.Fatal("The copy should no longer be a known game")
+	}
+
+	if _, isKnown := knownGames.GameName(originalDirPath); !isKnown {
+		t.Fatal("The original should still be a known game")
+	}
+
+	if names := testShortcutNames(t, shortcutsFilePath); len(names) != 1 || names[0] != "Pikmin" {
+		t.Fatal("Expected only the original's shortcut to remain - got", names)
+	}
+}
+
+func TestUpdateCreatesShortcutsForGamesWithTheSameNameAndDifferentLaunchers(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-shortman-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	info, shortcutsFilePath := createTestSteamData(t, rootDirPath)
+
+	dolphinExePath := path.Join(rootDirPath, "dolphin")
+	writeTestFile(t, dolphinExePath, "")
+
+	pcsx2ExePath := path.Join(rootDirPath, "pcsx2")
+	writeTestFile(t, pcsx2ExePath, "")
+
+	gameCubeDirPath := path.Join(rootDirPath, "gamecube")
+	ps2DirPath := path.Join(rootDirPath, "ps2")
+
+	gameCubeGameDirPath := path.Join(gameCubeDirPath, "Sonic Heroes (USA)")
+	ps2GameDirPath := path.Join(ps2DirPath, "Sonic Heroes (USA)")
+	sameLauncherGameDirPath := path.Join(gameCubeDirPath, "Sonic Heroes (Europe)")
+
+	for dirPath, data := range map[string]string{
+		gameCubeGameDirPath:     "gamecube",
+		ps2GameDirPath:          "ps2",
+		sameLauncherGameDirPath: "gamecube europe",
+	} {
+		writeTestFile(t, path.Join(dirPath, "game" + settings.FileExtension), "name = Sonic Heroes\n")
+		writeTestFile(t, path.Join(dirPath, "game.iso"), data)
+	}
+
+	app := settings.NewAppSettings()
+	app.AddGameCollection(gameCubeDirPath, "dolphin")
+	app.AddGameCollection(ps2DirPath, "pcsx2")
+
+	launchers := settings.NewLaunchersSettings()
+	launchers.AddOrUpdate(newTestLauncher("dolphin", dolphinExePath))
+	launchers.AddOrUpdate(newTestLauncher("pcsx2", pcsx2ExePath))
+
+	knownGames, _ := settings.LoadOrCreateKnownGamesSettings(rootDirPath)
+
+	manager := NewShortcutManager(Config{
+		App:              app,
+		KnownGames:       knownGames,
+		Launchers:        launchers,
+		IgnorePathPrefix: path.Join(rootDirPath, "settings"),
+	})
+
+	var wasSkipped bool
+
+	gameDirPaths := []string{gameCubeGameDirPath, ps2GameDirPath, sameLauncherGameDirPath}
+
+	for _, result := range manager.Update(gameDirPaths, true, info) {
+		if result.Outcome() == results.Failed || result.Outcome() == results.Duplicate {
+			t.Fatal(result.PrintableResult())
+		}
+
+		if result.Outcome() == results.Skipped {
+			if result.GameName() != sameLauncherGameDirPath {
+				t.Fatal(result.PrintableResult())
+			}
+
+			wasSkipped = true
+		}
+	}
+
+	// Steam names a shortcut's artwork after its name and executable,
+	// so only games with the same name and launcher are skipped.
+	if !wasSkipped {
+		t.Fatal("Expected the game with the same name and launcher to be skipped")
+	}
+
+	names := testShortcutNames(t, shortcutsFilePath)
+	if len(names) != 2 || names[0] != "Sonic Heroes" || names[1] != "Sonic Heroes" {
+		t.Fatal("Expected a shortcut for the game of each launcher - got", names)
+	}
+}
+
+func TestShortcutsOfGamesWithTheSameDirectoryNameAreKeptApart(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-shortman-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	info, shortcutsFilePath := createTestSteamData(t, rootDirPath)
+
+	dolphinExePath := path.Join(rootDirPath, "dolphin")
+	writeTestFile(t, dolphinExePath, "")
+
+	pcsx2ExePath := path.Join(rootDirPath, "pcsx2")
+	writeTestFile(t, pcsx2ExePath, "")
+
+	gameCubeDirPath := path.Join(rootDirPath, "gamecube")
+	ps2DirPath := path.Join(rootDirPath, "ps2")
+
+	gameCubeGameDirPath := path.Join(gameCubeDirPath, "Burnout 2")
+	ps2GameDirPath := path.Join(ps2DirPath, "Burnout 2")
+
+	writeTestFile(t, path.Join(gameCubeGameDirPath, "game.iso"), "gamecube")
+	writeTestFile(t, path.Join(ps2GameDirPath, "game.iso"), "ps2")
+
+	app := settings.NewAppSettings()
+	app.AddGameCollection(gameCubeDirPath, "dolphin")
+	app.AddGameCollection(ps2DirPath, "pcsx2")
+
+	launchers := settings.NewLaunchersSettings()
+	launchers.AddOrUpdate(newTestLauncher("dolphin", dolphinExePath))
+	launchers.AddOrUpdate(newTestLauncher("pcsx2", pcsx2ExePath))
+
+	knownGames, _ := settings.LoadOrCreateKnownGamesSettings(rootDirPath)
+
+	manager := NewShortcutManager(Config{
+		App:              app,
+		KnownGames:       knownGames,
+		Launchers:        launchers,
+		IgnorePathPrefix: path.Join(rootDirPath, "settings"),
+	})
+
+	checkResults := func(r []results.Result) {
+		for _, result := range r {
+			if result.Outcome() == results.Failed || result.Outcome() == results.Skipped {
+				t.Fatal(result.PrintableResult())
+			}
+		}
+	}
+
+	checkResults(manager.Update([]string{gameCubeGameDirPath, ps2GameDirPath}, true, info))
+
+	names := testShortcutNames(t, shortcutsFilePath)
+	if len(names) != 2 || names[0] != "Burnout 2" || names[1] != "Burnout 2" {
+		t.Fatal("Expected a shortcut for the game in each collection - got", names)
+	}
+
+	// Renaming one of the games renames only its own shortcut.
+	writeTestFile(t, path.Join(ps2GameDirPath, "game" + settings.FileExtension), "name = Burnout 2: Point of Impact\n")
+
+	checkResults(manager.Update([]string{ps2GameDirPath}, true, info))
+
+	names = testShortcutNames(t, shortcutsFilePath)
+	if len(names) != 2 || names[0] != "Burnout 2" || names[1] != "Burnout 2: Point of Impact" {
+		t.Fatal("Expected only the renamed game's shortcut to change - got", names)
+	}
+
+	// Deleting one of the games deletes only its own shortcut.
+	err = os.RemoveAll(gameCubeGameDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	checkResults(manager.Delete([]string{gameCubeGameDirPath}, true, info))
+
+	names = testShortcutNames(t, shortcutsFilePath)
+	if len(names) != 1 || names[0] != "Burnout 2: Point of Impact" {
+		t.Fatal("Expected only the other collection's shortcut to remain - got", names)
+	}
+}
+
+// createTestSteamData creates Steam's data directory for one Steam user
+// in the root directory. It returns the Steam data information and the
+// path to the user's shortcuts file.
+func createTestSteamData(t *testing.T, rootDirPath string) (steamw.DataInfo, string) {
+	steamUserId := "123"
+	steamDirPath := path.Join(rootDirPath, "steam")
+
+	err := os.MkdirAll(path.Join(locations.UserIdDirPath(steamDirPath, steamUserId), "config"), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err := steamw.NewSteamDataInfo(steamDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return info, locations.ShortcutsFilePath(steamDirPath, steamUserId)
+}
+
+func writeTestFile(t *testing.T, filePath string, data string) {
+	err := os.MkdirAll(path.Dir(filePath), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = ioutil.WriteFile(filePath, []byte(data), 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+}
+
+func newTestLauncher(name string, exePath string) settings.Launcher {
+	launcher := settings.NewLauncher()
+	launcher.SetName(name)
+	launcher.SetExePath(exePath)
+	launcher.SetGameFileSuffixes([]string{".iso"})
+
+	return launcher
+}
+
+func testShortcutNames(t *testing.T, shortcutsFilePath string) []string {
+	f, err := os.Open(shortcutsFilePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+
+	scs, err := shortcuts.ReadFile(f)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var names []string
+	for _, s := range scs {
+		names = append(names, s.AppName)
+	}
+
+	return names
+}
@@ -0,0 +1,53 @@
+package steamw
+
+import (
+	"hash/crc32"
+	"strconv"
+)
+
+// ShortcutIds are the IDs that Steam derives from a non-Steam game
+// shortcut's executable path and name. Tools that work with Steam's
+// shortcuts, such as artwork scrapers and controller configuration
+// managers, refer to shortcuts using these IDs.
+type ShortcutIds struct {
+	// AppId is the 32-bit app ID. It names the shortcut's library
+	// artwork and controller configuration.
+	AppId uint32
+
+	// LegacyGridId is the 64-bit ID that names the shortcut's
+	// legacy grid image.
+	LegacyGridId uint64
+}
+
+// AppIdString returns the app ID in base 10.
+func (o ShortcutIds) AppIdString() string {
+	return strconv.FormatUint(uint64(o.AppId), 10)
+}
+
+// LegacyGridIdString returns the legacy grid ID in base 10.
+func (o ShortcutIds) LegacyGridIdString() string {
+	return strconv.FormatUint(o.LegacyGridId, 10)
+}
+
+// NewShortcutIds returns the IDs of the shortcut described by
+// the NewShortcutConfig.
+func NewShortcutIds(config NewShortcutConfig) ShortcutIds {
+	config.clean()
+
+	return shortcutIds(config.Name, config.ExePath)
+}
+
+func shortcutIds(name string, exePath string) ShortcutIds {
+	appId := shortcutAppId(name, exePath)
+
+	return ShortcutIds{
+		AppId:        appId,
+		LegacyGridId: uint64(appId) << 32 | 0x02000000,
+	}
+}
+
+// shortcutAppId returns the 32-bit app ID that Steam derives from a
+// non-Steam game shortcut's executable path and name.
+func shortcutAppId(name string, exePath string) uint32 {
+	return crc32.ChecksumIEEE([]byte(exePath + name)) | 0x80000000
+}
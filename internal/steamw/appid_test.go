@@ -0,0 +1,29 @@
+package steamw
+
+import (
+	"testing"
+
+	"github.com/stephen-fox/steamutil/naming"
+)
+
+func TestNewShortcutIds(t *testing.T) {
+	config := NewShortcutConfig{
+		Name:    "Pikmin",
+		ExePath: `D:\Program Files\Dolphin\Dolphin.exe`,
+	}
+
+	ids := NewShortcutIds(config)
+
+	expected := naming.LegacyNonSteamGameId("Pikmin", `"D:\Program Files\Dolphin\Dolphin.exe"`)
+	if ids.LegacyGridIdString() != expected {
+		t.Fatal("Expected legacy grid ID '" + expected + "' - got '" + ids.LegacyGridIdString() + "'")
+	}
+
+	if uint64(ids.AppId) != ids.LegacyGridId >> 32 {
+		t.Fatal("The app ID should be the top 32 bits of the legacy grid ID - got", ids.AppId)
+	}
+
+	if ids.AppId & 0x80000000 == 0 {
+		t.Fatal("The app ID's high bit should be set - got", ids.AppId)
+	}
+}
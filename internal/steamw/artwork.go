@@ -0,0 +1,108 @@
+package steamw
+
+import (
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/stephen-fox/steamutil/locations"
+)
+
+const (
+	portraitArtworkSuffix = "p"
+	heroArtworkSuffix     = "_hero"
+	logoArtworkSuffix     = "_logo"
+)
+
+var (
+	artworkSuffixes = []string{portraitArtworkSuffix, heroArtworkSuffix, logoArtworkSuffix}
+)
+
+// artwork is an image shown in the Steam library. Unlike the legacy grid
+// image, artwork files are named after the shortcut's 32-bit app ID.
+type artwork struct {
+	kind           string
+	sourceFilePath string
+	suffix         string
+}
+
+func (o NewShortcutConfig) artwork() []artwork {
+	return []artwork{
+		{kind: "portrait", sourceFilePath: o.PortraitImagePath, suffix: portraitArtworkSuffix},
+		{kind: "hero", sourceFilePath: o.HeroImagePath, suffix: heroArtworkSuffix},
+		{kind: "logo", sourceFilePath: o.LogoImagePath, suffix: logoArtworkSuffix},
+	}
+}
+
+func artworkFilePath(gridDirPath string, appId uint32, suffix string, extension string) string {
+	return path.Join(gridDirPath, strconv.FormatUint(uint64(appId), 10) + suffix + extension)
+}
+
+// addOrRemoveShortcutArtwork copies the game's artwork into the Steam
+// user's grid directory. Artwork that the game no longer has is removed.
+func addOrRemoveShortcutArtwork(config NewShortcutConfig, steamUserId string) error {
+	gridDirPath := locations.GridDirPath(config.Info.DataLocations.RootDirPath(), steamUserId)
+	appId := shortcutAppId(config.Name, config.ExePath)
+
+	for _, a := range config.artwork() {
+		destFilePathNoExt := artworkFilePath(gridDirPath, appId, a.suffix, "")
+
+		var err error
+		if len(a.sourceFilePath) > 0 {
+			err = installImage(a.sourceFilePath, destFilePathNoExt)
+		} else {
+			err = removeInstalledImage(destFilePathNoExt)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeShortcutArtwork removes all of the shortcut's artwork from the
+// Steam user's grid directory.
+func removeShortcutArtwork(dataLocations locations.DataVerifier, steamUserId string, name string, exePath string) error {
+	gridDirPath := locations.GridDirPath(dataLocations.RootDirPath(), steamUserId)
+	appId := shortcutAppId(name, exePath)
+
+	for _, suffix := range artworkSuffixes {
+		err := removeInstalledImage(artworkFilePath(gridDirPath, appId, suffix, ""))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// moveShortcutArtwork renames any artwork belonging to the old shortcut
+// name and executable so that it belongs to the new ones.
+func moveShortcutArtwork(dataLocations locations.DataVerifier, steamUserId string, oldName string, oldExePath string, newName string, newExePath string) error {
+	gridDirPath := locations.GridDirPath(dataLocations.RootDirPath(), steamUserId)
+	oldAppId := shortcutAppId(oldName, oldExePath)
+	newAppId := shortcutAppId(newName, newExePath)
+
+	for _, suffix := range artworkSuffixes {
+		for _, extension := range installedImageExtensions {
+			oldFilePath := artworkFilePath(gridDirPath, oldAppId, suffix, extension)
+
+			err := os.Rename(oldFilePath, artworkFilePath(gridDirPath, newAppId, suffix, extension))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func removeIfExists(filePath string) error {
+	err := os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package steamw
+
+import (
+	"sort"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/steamutil/locations"
+	"github.com/stephen-fox/steamutil/shortcuts"
+)
+
+// Batch collects changes to Steam users' shortcuts in memory. Each Steam
+// user's shortcuts file is read at most once, and is written at most once
+// when the batch is flushed, no matter how many games are changed.
+type Batch struct {
+	usersToShortcuts map[string]*userShortcuts
+	pending          []pendingResult
+	changes          []PendingChange
+}
+
+// userShortcuts is the in-memory copy of a Steam user's shortcuts file.
+type userShortcuts struct {
+	info      DataInfo
+	shortcuts []shortcuts.Shortcut
+	existed   bool
+	changed   bool
+	readErr   error
+}
+
+// pendingResult is the result of a change to a Steam user's shortcuts.
+// The result is not known until the user's shortcuts file is saved.
+type pendingResult struct {
+	steamUserId string
+	gameDirPath string
+	ids         ShortcutIds
+
+	// complete finishes the change after the shortcuts file is saved,
+	// and returns the result of the change.
+	complete func() results.Result
+
+	// fail returns the result of the change if the shortcuts file
+	// could not be saved. The result of complete is always used
+	// if fail is nil.
+	fail func(reason string) results.Result
+
+	// deferred returns the result of the change if it was added to
+	// the pending changes. The result of complete is always used
+	// if deferred is nil.
+	deferred func(reason string) results.Result
+}
+
+// annotate adds the game's directory and the shortcut's IDs to the result.
+func (o pendingResult) annotate(result results.Result) []results.Result {
+	r := results.WithGameDirPath([]results.Result{result}, o.gameDirPath)
+
+	if o.ids.AppId != 0 {
+		r = results.WithShortcutIds(r, o.ids.AppIdString(), o.ids.LegacyGridIdString())
+	}
+
+	return r
+}
+
+// Flush saves the shortcuts files of the Steam users whose shortcuts were
+// changed. The results of the batched changes are returned in the order
+// in which the changes were made. The batch is empty afterwards.
+func (o *Batch) Flush() []results.Result {
+	steamUserIds := make([]string, 0, len(o.usersToShortcuts))
+	for steamUserId := range o.usersToShortcuts {
+		steamUserIds = append(steamUserIds, steamUserId)
+	}
+	sort.Strings(steamUserIds)
+
+	usersToErrs := make(map[string]error)
+
+	for _, steamUserId := range steamUserIds {
+		current := o.usersToShortcuts[steamUserId]
+		if !current.changed {
+			continue
+		}
+
+		err := writeShortcutsFile(current.info, steamUserId, current.shortcuts)
+		if err != nil {
+			usersToErrs[steamUserId] = err
+		}
+	}
+
+	var r []results.Result
+
+	for _, p := range o.pending {
+		var result results.Result
+
+		err, failed := usersToErrs[p.steamUserId]
+		if failed && p.fail != nil {
+			result = p.fail("failed to save shortcuts file - " + err.Error())
+		} else {
+			result = p.complete()
+		}
+
+		r = append(r, p.annotate(result)...)
+	}
+
+	o.reset()
+
+	return r
+}
+
+// FlushOrDefer saves the batch's changes if Steam is not running, followed
+// by any pending changes that were not superseded by the batch. If Steam
+// is running, the batch's changes are added to the pending changes
+// instead. The batch is empty afterwards.
+//
+// The changes are saved if it cannot be determined whether Steam
+// is running.
+func (o *Batch) FlushOrDefer(pending *PendingChanges, info DataInfo) []results.Result {
+	isRunning, err := IsSteamRunning()
+	if err == nil && isRunning {
+		return o.deferTo(pending)
+	}
+
+	if pending.IsEmpty() {
+		return o.Flush()
+	}
+
+	shortcutIds := make(map[string]bool)
+	for _, change := range o.changes {
+		shortcutIds[change.shortcutId()] = true
+	}
+	pending.discard(shortcutIds)
+
+	r := o.Flush()
+
+	return append(r, pending.Apply(info)...)
+}
+
+// deferTo adds the batch's changes to the pending changes without
+// saving them to the Steam users' shortcuts files.
+func (o *Batch) deferTo(pending *PendingChanges) []results.Result {
+	previous := append([]PendingChange{}, pending.changes...)
+
+	for _, change := range o.changes {
+		pending.add(change)
+	}
+
+	saveErr := pending.save()
+	if saveErr != nil {
+		pending.changes = previous
+	}
+
+	var r []results.Result
+
+	for _, p := range o.pending {
+		var result results.Result
+
+		if p.deferred == nil {
+			result = p.complete()
+		} else if saveErr != nil {
+			result = p.fail("failed to save pending changes - " + saveErr.Error())
+		} else {
+			result = p.deferred(deferredReason)
+		}
+
+		r = append(r, p.annotate(result)...)
+	}
+
+	o.reset()
+
+	return r
+}
+
+func (o *Batch) reset() {
+	o.usersToShortcuts = make(map[string]*userShortcuts)
+	o.pending = nil
+	o.changes = nil
+}
+
+// userShortcuts returns the Steam user's shortcuts, reading them from
+// the user's shortcuts file the first time they are needed.
+func (o *Batch) userShortcuts(info DataInfo, steamUserId string) (*userShortcuts, error) {
+	current, ok := o.usersToShortcuts[steamUserId]
+	if !ok {
+		scs, existed, err := readShortcutsFile(locations.ShortcutsFilePath(info.DataLocations.RootDirPath(), steamUserId))
+
+		current = &userShortcuts{
+			info:      info,
+			shortcuts: scs,
+			existed:   existed,
+			readErr:   err,
+		}
+
+		o.usersToShortcuts[steamUserId] = current
+	}
+
+	return current, current.readErr
+}
+
+// addResult adds a result that does not depend on saving the
+// Steam user's shortcuts file.
+func (o *Batch) addResult(steamUserId string, gameDirPath string, result results.Result) {
+	o.addPending(pendingResult{
+		steamUserId: steamUserId,
+		gameDirPath: gameDirPath,
+		complete: func() results.Result {
+			return result
+		},
+	})
+}
+
+func (o *Batch) addPending(p pendingResult) {
+	o.pending = append(o.pending, p)
+}
+
+func NewBatch() *Batch {
+	return &Batch{
+		usersToShortcuts: make(map[string]*userShortcuts),
+	}
+}
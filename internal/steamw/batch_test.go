@@ -0,0 +1,161 @@
+package steamw
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/steamutil/locations"
+)
+
+func TestBatchFlush(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-batch-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	steamUserId := "123"
+
+	err = os.MkdirAll(path.Join(locations.UserIdDirPath(rootDirPath, steamUserId), "config"), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err := NewSteamDataInfo(rootDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	batch := NewBatch()
+
+	for _, name := range []string{"Pikmin", "Pikmin 2"} {
+		batch.CreateOrUpdateShortcut(NewShortcutConfig{
+			ShortcutId:  GameShortcutId("/games/" + name),
+			Name:        name,
+			ExePath:     "/usr/bin/dolphin",
+			GameDirPath: "/games/" + name,
+			Info:        info,
+		})
+	}
+
+	batch.DeleteShortcut(DeleteShortcutConfig{
+		ShortcutId:  GameShortcutId("/games/Pikmin"),
+		GameName:    "Pikmin",
+		GameDirPath: "/games/Pikmin",
+		Info:        info,
+	})
+
+	shortcutsFilePath := locations.ShortcutsFilePath(rootDirPath, steamUserId)
+
+	_, statErr := os.Stat(shortcutsFilePath)
+	if statErr == nil {
+		t.Fatal("The shortcuts file should not be written until the batch is flushed")
+	}
+
+	r := batch.Flush()
+	if len(r) != 3 {
+		t.Fatal("Expected 3 results - got", len(r))
+	}
+
+	for _, result := range r {
+		if result.Outcome() == results.Failed {
+			t.Fatal(result.PrintableResult())
+		}
+
+		if len(result.GameDirPath()) == 0 {
+			t.Fatal("Result is missing the game's directory - " + result.PrintableResult())
+		}
+	}
+
+	scs, _, err := readShortcutsFile(shortcutsFilePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(scs) != 1 || scs[0].AppName != "Pikmin 2" || scs[0].Id != 0 {
+		t.Fatal("Unexpected shortcuts after flush -", scs)
+	}
+
+	if len(batch.Flush()) != 0 {
+		t.Fatal("The batch should be empty after it is flushed")
+	}
+}
+
+func TestBatchReportsUpdatedShortcutWithWarnings(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-batch-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	steamUserId := "123"
+
+	err = os.MkdirAll(path.Join(locations.UserIdDirPath(rootDirPath, steamUserId), "config"), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err := NewSteamDataInfo(rootDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	config := NewShortcutConfig{
+		ShortcutId:  GameShortcutId("/games/Pikmin"),
+		Name:        "Pikmin",
+		ExePath:     "/usr/bin/dolphin",
+		GameDirPath: "/games/Pikmin",
+		Info:        info,
+	}
+
+	batch := NewBatch()
+
+	batch.CreateOrUpdateShortcut(config)
+
+	for _, result := range batch.Flush() {
+		if result.Operation() != results.CreateShortcut || result.Outcome() != results.Succeeded {
+			t.Fatal(result.PrintableResult())
+		}
+	}
+
+	config.Warnings = []string{"no icon was provided"}
+
+	batch.CreateOrUpdateShortcut(config)
+
+	r := batch.Flush()
+	if len(r) != 1 {
+		t.Fatal("Expected 1 result - got", len(r))
+	}
+
+	b := bytes.NewBuffer(nil)
+
+	err = results.WriteJsonLines(r, b)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var decoded map[string]interface{}
+
+	err = json.Unmarshal(b.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exp := map[string]string{
+		"operation":     results.UpdateShortcut.String(),
+		"outcome":       results.SucceededWithWarning.String(),
+		"steam_user_id": steamUserId,
+		"reason":        "no icon was provided",
+	}
+
+	for k, v := range exp {
+		if decoded[k] != v {
+			t.Fatal("Unexpected value for '" + k + "' -", decoded[k])
+		}
+	}
+}
@@ -1,16 +1,49 @@
 package steamw
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/stephen-fox/steamutil/locations"
 )
 
+const (
+	OverrideRoot RootSource = "settings override"
+	DefaultRoot  RootSource = "default location"
+)
+
+// RootSource describes how the Steam data directory was chosen.
+type RootSource string
+
+func (o RootSource) String() string {
+	return string(o)
+}
+
 type DataInfo struct {
 	DataLocations locations.DataVerifier
 	IdsToDirPaths map[string]string
+	IdsToAliases  map[string][]string
+	RootSource    RootSource
+
+	// BackupsDirPath is the directory where each Steam user's
+	// shortcuts file is backed up before it is modified. No
+	// backups are made if it is empty.
+	BackupsDirPath string
+
+	// ImageCacheDirPath is the directory where images that Steam
+	// cannot display, or that do not have the dimensions that Steam
+	// expects, are converted and resized. Such icons are used as-is
+	// and such images are not resized if it is empty.
+	ImageCacheDirPath string
 }
 
-func NewSteamDataInfo() (DataInfo, error) {
-	v, err := locations.NewDataVerifier()
+// NewSteamDataInfo finds the Steam data directory and its users. If
+// rootDirPathOverride is not empty, it is used instead of searching
+// for the data directory.
+func NewSteamDataInfo(rootDirPathOverride string) (DataInfo, error) {
+	v, source, err := findDataVerifier(rootDirPathOverride)
 	if err != nil {
 		return DataInfo{}, err
 	}
@@ -20,8 +53,107 @@ func NewSteamDataInfo() (DataInfo, error) {
 		return DataInfo{}, err
 	}
 
+	// Account names are only needed for filtering Steam users, so
+	// failing to load them is not fatal.
+	idsToAliases, _ := loadUserIdsToAliases(v.RootDirPath())
+
 	return DataInfo{
 		DataLocations: v,
 		IdsToDirPaths: idsToDirs,
+		IdsToAliases:  idsToAliases,
+		RootSource:    source,
 	}, nil
-}
\ No newline at end of file
+}
+
+func findDataVerifier(rootDirPathOverride string) (locations.DataVerifier, RootSource, error) {
+	if len(strings.TrimSpace(rootDirPathOverride)) > 0 {
+		if !isSteamRoot(rootDirPathOverride) {
+			return nil, "", errors.New("The Steam root directory override '" +
+				rootDirPathOverride + "' does not contain a Steam user data directory")
+		}
+
+		return &rootDataVerifier{rootDirPath: rootDirPathOverride}, OverrideRoot, nil
+	}
+
+	for _, candidate := range platformRootCandidates() {
+		if isSteamRoot(candidate.dirPath) {
+			return &rootDataVerifier{rootDirPath: candidate.dirPath}, candidate.source, nil
+		}
+	}
+
+	v, err := locations.NewDataVerifier()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return v, DefaultRoot, nil
+}
+
+type rootCandidate struct {
+	dirPath string
+	source  RootSource
+}
+
+func isSteamRoot(dirPath string) bool {
+	info, statErr := os.Stat(locations.UserDataDirPath(dirPath))
+	if statErr != nil {
+		return false
+	}
+
+	return info.IsDir()
+}
+
+// rootDataVerifier is a locations.DataVerifier for a specific Steam
+// data directory.
+type rootDataVerifier struct {
+	rootDirPath string
+}
+
+func (o *rootDataVerifier) RootDirPath() string {
+	return o.rootDirPath
+}
+
+func (o *rootDataVerifier) UserDataDirPath() (string, os.FileInfo, error) {
+	return statPath(locations.UserDataDirPath(o.rootDirPath))
+}
+
+func (o *rootDataVerifier) UserIdsToDataDirPaths() (map[string]string, error) {
+	idsToDirs := make(map[string]string)
+
+	dirPath, _, err := o.UserDataDirPath()
+	if err != nil {
+		return idsToDirs, err
+	}
+
+	infos, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return idsToDirs, err
+	}
+
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+
+		idsToDirs[info.Name()] = locations.UserIdDirPath(o.rootDirPath, info.Name())
+	}
+
+	return idsToDirs, nil
+}
+
+func (o *rootDataVerifier) ShortcutsFilePath(userId string) (string, os.FileInfo, error) {
+	return statPath(locations.ShortcutsFilePath(o.rootDirPath, userId))
+}
+
+func (o *rootDataVerifier) GridDirPath(userId string) (string, os.FileInfo, error) {
+	return statPath(locations.GridDirPath(o.rootDirPath, userId))
+}
+
+func statPath(p string) (string, os.FileInfo, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return p, info, nil
+}
@@ -0,0 +1,211 @@
+package steamw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+const (
+	icoDirSize        = 6
+	icoDirEntrySize   = 16
+	icoTypeIcon       = 1
+	bitmapInfoMinSize = 40
+	bitmapRgb         = 0
+)
+
+var (
+	pngSignature = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// icoEntry describes one of the images in an ICO file.
+type icoEntry struct {
+	width    int
+	height   int
+	bitCount int
+	size     uint32
+	offset   uint32
+}
+
+// decodeIco decodes the largest image in an ICO file. ICO images are
+// stored either as PNG files, or as device independent bitmaps
+// followed by a transparency mask.
+func decodeIco(r io.Reader) (image.Image, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < icoDirSize || binary.LittleEndian.Uint16(raw[0:2]) != 0 ||
+		binary.LittleEndian.Uint16(raw[2:4]) != icoTypeIcon {
+		return nil, errors.New("not an ICO file")
+	}
+
+	numEntries := int(binary.LittleEndian.Uint16(raw[4:6]))
+	if numEntries == 0 {
+		return nil, errors.New("the ICO file contains no images")
+	}
+
+	if len(raw) < icoDirSize + numEntries * icoDirEntrySize {
+		return nil, errors.New("the ICO file's directory is truncated")
+	}
+
+	var best icoEntry
+
+	for i := 0; i < numEntries; i++ {
+		b := raw[icoDirSize + i * icoDirEntrySize:]
+
+		entry := icoEntry{
+			width:    int(b[0]),
+			height:   int(b[1]),
+			bitCount: int(binary.LittleEndian.Uint16(b[6:8])),
+			size:     binary.LittleEndian.Uint32(b[8:12]),
+			offset:   binary.LittleEndian.Uint32(b[12:16]),
+		}
+
+		// A dimension of zero means 256 pixels.
+		if entry.width == 0 {
+			entry.width = 256
+		}
+		if entry.height == 0 {
+			entry.height = 256
+		}
+
+		if i == 0 || entry.width * entry.height > best.width * best.height ||
+			(entry.width * entry.height == best.width * best.height && entry.bitCount > best.bitCount) {
+			best = entry
+		}
+	}
+
+	end := uint64(best.offset) + uint64(best.size)
+	if end > uint64(len(raw)) {
+		return nil, errors.New("the ICO file's image data is truncated")
+	}
+
+	data := raw[best.offset:end]
+
+	if bytes.HasPrefix(data, pngSignature) {
+		return png.Decode(bytes.NewReader(data))
+	}
+
+	return decodeIcoBitmap(data)
+}
+
+// decodeIcoBitmap decodes a device independent bitmap stored in an ICO
+// file. The bitmap's height includes the transparency mask that
+// follows the pixels.
+func decodeIcoBitmap(data []byte) (image.Image, error) {
+	if len(data) < bitmapInfoMinSize {
+		return nil, errors.New("the ICO file's bitmap header is truncated")
+	}
+
+	headerSize := int(binary.LittleEndian.Uint32(data[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:12]))) / 2
+	bitCount := int(binary.LittleEndian.Uint16(data[14:16]))
+	compression := binary.LittleEndian.Uint32(data[16:20])
+	colorsUsed := int(binary.LittleEndian.Uint32(data[32:36]))
+
+	if width <= 0 || height <= 0 || headerSize < bitmapInfoMinSize || headerSize > len(data) {
+		return nil, errors.New("the ICO file's bitmap header is invalid")
+	}
+
+	if compression != bitmapRgb {
+		return nil, errors.New("compressed ICO bitmaps are not supported")
+	}
+
+	var palette []color.NRGBA
+
+	switch bitCount {
+	case 1, 4, 8:
+		if colorsUsed == 0 {
+			colorsUsed = 1 << uint(bitCount)
+		}
+
+		if headerSize + colorsUsed * 4 > len(data) {
+			return nil, errors.New("the ICO file's bitmap palette is truncated")
+		}
+
+		for i := 0; i < colorsUsed; i++ {
+			b := data[headerSize + i * 4:]
+			palette = append(palette, color.NRGBA{R: b[2], G: b[1], B: b[0], A: 0xff})
+		}
+	case 24, 32:
+	default:
+		return nil, errors.New("ICO bitmaps with " + strconv.Itoa(bitCount) + " bits per pixel are not supported")
+	}
+
+	pixelsOffset := headerSize + len(palette) * 4
+	stride := (width * bitCount + 31) / 32 * 4
+	maskOffset := pixelsOffset + stride * height
+	maskStride := (width + 31) / 32 * 4
+
+	if maskOffset > len(data) {
+		return nil, errors.New("the ICO file's bitmap pixels are truncated")
+	}
+
+	// Older icons omit the transparency mask.
+	hasMask := maskOffset + maskStride * height <= len(data)
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+
+	for y := 0; y < height; y++ {
+		// Rows are stored bottom-up.
+		row := data[pixelsOffset + (height - 1 - y) * stride:]
+
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+
+			switch bitCount {
+			case 32:
+				c = color.NRGBA{R: row[x*4+2], G: row[x*4+1], B: row[x*4], A: row[x*4+3]}
+				if c.A != 0 {
+					hasAlpha = true
+				}
+			case 24:
+				c = color.NRGBA{R: row[x*3+2], G: row[x*3+1], B: row[x*3], A: 0xff}
+			default:
+				bitOffset := x * bitCount
+				index := int(row[bitOffset/8] >> uint(8 - bitCount - bitOffset % 8) & (1 << uint(bitCount) - 1))
+				if index < len(palette) {
+					c = palette[index]
+				}
+			}
+
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	// 32 bit bitmaps normally carry their own transparency. The mask
+	// is only used if every pixel's alpha is zero.
+	if bitCount == 32 && hasAlpha {
+		return img, nil
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			transparent := false
+			if hasMask {
+				row := data[maskOffset + (height - 1 - y) * maskStride:]
+				transparent = row[x/8] & (0x80 >> uint(x % 8)) != 0
+			}
+
+			c := img.NRGBAAt(x, y)
+			if transparent {
+				c.A = 0
+			} else {
+				c.A = 0xff
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	return img, nil
+}
@@ -0,0 +1,92 @@
+package steamw
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestDecodeIco(t *testing.T) {
+	// A 2x2 32 bit bitmap. Rows are stored bottom-up in BGRA order.
+	bitmap := bytes.NewBuffer(nil)
+	header := []uint32{bitmapInfoMinSize, 2, 4}
+	for _, v := range header {
+		binary.Write(bitmap, binary.LittleEndian, v)
+	}
+	binary.Write(bitmap, binary.LittleEndian, uint16(1))
+	binary.Write(bitmap, binary.LittleEndian, uint16(32))
+	bitmap.Write(make([]byte, bitmapInfoMinSize - 16))
+	bitmap.Write([]byte{
+		0, 0, 255, 255, 0, 255, 0, 255,
+		255, 0, 0, 255, 0, 0, 0, 0,
+	})
+	// Transparency mask rows are padded to 4 bytes.
+	bitmap.Write(make([]byte, 8))
+
+	// A 4x4 PNG, which should be preferred because it is larger.
+	large := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	large.SetNRGBA(3, 3, color.NRGBA{R: 1, G: 2, B: 3, A: 4})
+	pngData := bytes.NewBuffer(nil)
+	err := png.Encode(pngData, large)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	ico := func(entries ...[]byte) []byte {
+		b := bytes.NewBuffer(nil)
+		binary.Write(b, binary.LittleEndian, []uint16{0, icoTypeIcon, uint16(len(entries))})
+
+		offset := icoDirSize + len(entries) * icoDirEntrySize
+		for _, data := range entries {
+			size := byte(2)
+			if bytes.HasPrefix(data, pngSignature) {
+				size = 4
+			}
+			b.Write([]byte{size, size, 0, 0, 1, 0, 32, 0})
+			binary.Write(b, binary.LittleEndian, []uint32{uint32(len(data)), uint32(offset)})
+			offset += len(data)
+		}
+
+		for _, data := range entries {
+			b.Write(data)
+		}
+
+		return b.Bytes()
+	}
+
+	img, err := decodeIco(bytes.NewReader(ico(bitmap.Bytes())))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := map[image.Point]color.NRGBA{
+		{X: 0, Y: 0}: {R: 0, G: 0, B: 255, A: 255},
+		{X: 1, Y: 0}: {R: 0, G: 0, B: 0, A: 0},
+		{X: 0, Y: 1}: {R: 255, G: 0, B: 0, A: 255},
+		{X: 1, Y: 1}: {R: 0, G: 255, B: 0, A: 255},
+	}
+
+	for p, c := range expected {
+		actual := color.NRGBAModel.Convert(img.At(p.X, p.Y)).(color.NRGBA)
+		if actual != c {
+			t.Fatal("Pixel", p, "should be", c, "- got", actual)
+		}
+	}
+
+	img, err = decodeIco(bytes.NewReader(ico(bitmap.Bytes(), pngData.Bytes())))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if img.Bounds().Dx() != 4 {
+		t.Fatal("The largest image should be decoded - got width", img.Bounds().Dx())
+	}
+
+	_, err = decodeIco(bytes.NewReader([]byte("not an icon")))
+	if err == nil {
+		t.Fatal("Decoding a file that is not an ICO file should fail")
+	}
+}
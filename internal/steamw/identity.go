@@ -0,0 +1,68 @@
+package steamw
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+
+	"github.com/stephen-fox/steamutil/shortcuts"
+)
+
+const (
+	managedShortcutIdPrefix = "grundy:"
+)
+
+// GameShortcutId returns the identity of the shortcut that the application
+// manages for the specified game directory.
+//
+// The identity is stored in the shortcut's ShortcutPath field. This allows
+// the application to find its shortcuts even if they are renamed, and to
+// leave shortcuts that it does not own untouched. Steam only sets the field
+// for shortcuts that it creates from other programs' shortcuts, such as
+// .desktop files on Linux, so shortcuts whose ShortcutPath is already set
+// are never claimed by the application.
+func GameShortcutId(gameDirPath string) string {
+	hash := sha1.Sum([]byte(filepath.Clean(gameDirPath)))
+
+	return managedShortcutIdPrefix + hex.EncodeToString(hash[:])
+}
+
+// findManagedShortcut returns the index of the shortcut with the specified
+// identity. Shortcuts created by older versions of the application have no
+// identity. Such a shortcut is only matched if its ShortcutPath is empty,
+// and its name and executable path match the provided legacy name and
+// executable path.
+func findManagedShortcut(scs []shortcuts.Shortcut, id string, legacyName string, legacyExePath string) (int, bool) {
+	if len(id) > 0 {
+		for i := range scs {
+			if scs[i].ShortcutPath == id {
+				return i, true
+			}
+		}
+	}
+
+	if len(legacyName) == 0 || len(legacyExePath) == 0 {
+		return 0, false
+	}
+
+	legacyExePath = trimDoubleQuotes(legacyExePath)
+
+	for i := range scs {
+		// The shortcut either belongs to another game, or Steam
+		// created it from another program's shortcut.
+		if len(scs[i].ShortcutPath) > 0 {
+			continue
+		}
+
+		if scs[i].AppName == legacyName && trimDoubleQuotes(scs[i].ExePath) == legacyExePath {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func trimDoubleQuotes(s string) string {
+	return strings.TrimPrefix(strings.TrimSuffix(s, "\""), "\"")
+}
@@ -0,0 +1,54 @@
+package steamw
+
+import (
+	"testing"
+
+	"github.com/stephen-fox/steamutil/shortcuts"
+)
+
+func TestFindManagedShortcut(t *testing.T) {
+	id := GameShortcutId("/games/gamecube/Pikmin")
+
+	scs := []shortcuts.Shortcut{
+		{Id: 0, AppName: "Pikmin", ExePath: "/usr/bin/something-else"},
+		{Id: 1, AppName: "Pikmin 2", ExePath: "/usr/bin/dolphin", ShortcutPath: id},
+	}
+
+	i, ok := findManagedShortcut(scs, id, "Pikmin", "/usr/bin/dolphin")
+	if !ok || i != 1 {
+		t.Fatal("Failed to match shortcut by its identity - got", i, ok)
+	}
+
+	_, ok = findManagedShortcut(scs, GameShortcutId("/games/gamecube/Other"), "Pikmin", "/usr/bin/dolphin")
+	if ok {
+		t.Fatal("A user-created shortcut with the same name should not be matched")
+	}
+}
+
+func TestFindManagedShortcutLegacy(t *testing.T) {
+	scs := []shortcuts.Shortcut{
+		{Id: 0, AppName: "Pikmin", ExePath: "/Applications/Dolphin App"},
+	}
+
+	i, ok := findManagedShortcut(scs, GameShortcutId("/games/gamecube/Pikmin"),
+		"Pikmin", "\"/Applications/Dolphin App\"")
+	if !ok || i != 0 {
+		t.Fatal("Failed to match legacy shortcut - got", i, ok)
+	}
+
+	scs[0].ShortcutPath = GameShortcutId("/games/other/Pikmin")
+
+	_, ok = findManagedShortcut(scs, GameShortcutId("/games/gamecube/Pikmin"),
+		"Pikmin", "/Applications/Dolphin App")
+	if ok {
+		t.Fatal("A shortcut owned by another game should not be matched")
+	}
+
+	scs[0].ShortcutPath = "/usr/share/applications/dolphin-emu.desktop"
+
+	_, ok = findManagedShortcut(scs, GameShortcutId("/games/gamecube/Pikmin"),
+		"Pikmin", "/Applications/Dolphin App")
+	if ok {
+		t.Fatal("A shortcut that Steam created from a .desktop file should not be matched")
+	}
+}
@@ -0,0 +1,328 @@
+package steamw
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+	"golang.org/x/image/draw"
+)
+
+const (
+	// ImageFitNone installs images as they are.
+	ImageFitNone ImageFit = "none"
+
+	// ImageFitLetterbox scales images that do not have the expected
+	// dimensions so that they fit inside of the expected dimensions
+	// without changing their aspect ratio. The remaining space
+	// is transparent.
+	ImageFitLetterbox ImageFit = "letterbox"
+
+	// ImageFitScale stretches images that do not have the expected
+	// dimensions to the expected dimensions.
+	ImageFitScale ImageFit = "scale"
+
+	// aspectRatioTolerance is the fraction by which an image's aspect
+	// ratio may differ from the expected aspect ratio.
+	aspectRatioTolerance = 0.01
+)
+
+var (
+	iconImageSpec     = imageSpec{kind: "icon"}
+	gridImageSpec     = imageSpec{kind: "grid", width: 460, height: 215}
+	portraitImageSpec = imageSpec{kind: "portrait", width: 600, height: 900}
+)
+
+// ImageFit determines what is done to images that do not have the
+// dimensions that Steam expects.
+type ImageFit string
+
+func (o ImageFit) String() string {
+	return string(o)
+}
+
+func (o ImageFit) pastTense() string {
+	switch o {
+	case ImageFitLetterbox:
+		return "letterboxed"
+	case ImageFitScale:
+		return "scaled"
+	}
+
+	return "kept"
+}
+
+// ParseImageFit parses an ImageFit. An empty string is ImageFitNone.
+func ParseImageFit(s string) (ImageFit, error) {
+	switch fit := ImageFit(strings.ToLower(strings.TrimSpace(s))); fit {
+	case "":
+		return ImageFitNone, nil
+	case ImageFitNone, ImageFitLetterbox, ImageFitScale:
+		return fit, nil
+	}
+
+	return ImageFitNone, errors.New("unknown image fit '" + s + "' - must be one of '" +
+		ImageFitNone.String() + "', '" + ImageFitLetterbox.String() + "', or '" +
+		ImageFitScale.String() + "'")
+}
+
+// imageSpec describes the dimensions that Steam expects for a kind of
+// image. Images with no width and height should be square.
+type imageSpec struct {
+	kind   string
+	width  int
+	height int
+}
+
+// problem describes why an image's dimensions are not what Steam
+// expects. An empty string is returned if the dimensions are fine.
+func (o imageSpec) problem(width int, height int) string {
+	actual := strconv.Itoa(width) + "x" + strconv.Itoa(height)
+
+	if o.width == 0 || o.height == 0 {
+		if width != height {
+			return o.kind + " image is " + actual + " pixels, but should be square"
+		}
+
+		return ""
+	}
+
+	expectedRatio := float64(o.width) / float64(o.height)
+	ratio := float64(width) / float64(height)
+	diff := (ratio - expectedRatio) / expectedRatio
+	if diff < 0 {
+		diff = -diff
+	}
+
+	if diff > aspectRatioTolerance || width < o.width {
+		return o.kind + " image is " + actual + " pixels, but should be " +
+			strconv.Itoa(o.width) + "x" + strconv.Itoa(o.height)
+	}
+
+	return ""
+}
+
+// target returns the dimensions that an image should be fitted to.
+func (o imageSpec) target(width int, height int) (int, int) {
+	if o.width == 0 || o.height == 0 {
+		if width > height {
+			return width, width
+		}
+
+		return height, height
+	}
+
+	return o.width, o.height
+}
+
+// fitImage letterboxes or scales the image to the specified dimensions.
+func fitImage(img image.Image, fit ImageFit, width int, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	dstRect := dst.Bounds()
+
+	if fit == ImageFitLetterbox {
+		srcWidth := img.Bounds().Dx()
+		srcHeight := img.Bounds().Dy()
+
+		scaledWidth := width
+		scaledHeight := srcHeight * width / srcWidth
+		if scaledHeight > height {
+			scaledHeight = height
+			scaledWidth = srcWidth * height / srcHeight
+		}
+
+		x := (width - scaledWidth) / 2
+		y := (height - scaledHeight) / 2
+		dstRect = image.Rect(x, y, x + scaledWidth, y + scaledHeight)
+	}
+
+	draw.CatmullRom.Scale(dst, dstRect, img, img.Bounds(), draw.Src, nil)
+
+	return dst
+}
+
+// preparedImage is the result of preparing an image for Steam.
+type preparedImage struct {
+	// filePath is the path to the image that should be installed.
+	// It is either the source image, or a processed copy of it in
+	// the image cache directory.
+	filePath string
+
+	// warning describes a problem with the image's dimensions.
+	warning string
+}
+
+// prepareImage checks that the image has the dimensions that Steam
+// expects. An image that does not is letterboxed or scaled according
+// to fit, and saved as a PNG in the cache directory. Images that are
+// not in one of the supported formats are also converted to PNG.
+//
+// Processed images are named after cacheName and a hash of the source
+// image and the processing, so they are only processed again when
+// something changes. Older processed copies are removed. Nothing is
+// saved if planOnly is true.
+func prepareImage(sourceFilePath string, spec imageSpec, supportedExtensions []string, fit ImageFit, cacheDirPath string, cacheName string, planOnly bool) (preparedImage, error) {
+	prepared := preparedImage{
+		filePath: sourceFilePath,
+	}
+
+	data, err := ioutil.ReadFile(sourceFilePath)
+	if err != nil {
+		return prepared, err
+	}
+
+	img, err := decodeImage(data, sourceFilePath)
+	if err != nil {
+		return prepared, err
+	}
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	prepared.warning = spec.problem(width, height)
+
+	shouldFit := len(prepared.warning) > 0 && fit != ImageFitNone
+	shouldConvert := !isOneOf(imageExtension(sourceFilePath), supportedExtensions)
+	targetWidth, targetHeight := spec.target(width, height)
+
+	if shouldFit {
+		prepared.warning = prepared.warning + " - " + fit.pastTense() + " to " +
+			strconv.Itoa(targetWidth) + "x" + strconv.Itoa(targetHeight)
+	}
+
+	if planOnly || len(cacheDirPath) == 0 {
+		return prepared, nil
+	}
+
+	if !shouldFit && !shouldConvert {
+		return prepared, pruneCachedImages(cacheDirPath, cacheName, "")
+	}
+
+	hash := sha1.New()
+	hash.Write(data)
+	if shouldFit {
+		hash.Write([]byte(fit))
+	}
+
+	cachedFilePath := path.Join(cacheDirPath, cacheName + "-" +
+		hex.EncodeToString(hash.Sum(nil))[:16] + convertedImageExtension)
+
+	_, statErr := os.Stat(cachedFilePath)
+	if statErr != nil {
+		if shouldFit {
+			img = fitImage(img, fit, targetWidth, targetHeight)
+		}
+
+		processed, err := encodePng(img)
+		if err != nil {
+			return prepared, err
+		}
+
+		err = os.MkdirAll(cacheDirPath, imageDirMode)
+		if err != nil {
+			return prepared, err
+		}
+
+		err = atomicfile.WriteFile(cachedFilePath, processed, defaultShortcutsFileMode)
+		if err != nil {
+			return prepared, err
+		}
+	}
+
+	prepared.filePath = cachedFilePath
+
+	return prepared, pruneCachedImages(cacheDirPath, cacheName, cachedFilePath)
+}
+
+// pruneCachedImages removes the processed copies of an image from the
+// cache directory, except for the one that should be kept.
+func pruneCachedImages(cacheDirPath string, cacheName string, keepFilePath string) error {
+	infos, err := ioutil.ReadDir(cacheDirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	for _, fileInfo := range infos {
+		if fileInfo.IsDir() || !strings.HasPrefix(fileInfo.Name(), cacheName + "-") {
+			continue
+		}
+
+		filePath := path.Join(cacheDirPath, fileInfo.Name())
+		if filePath == keepFilePath {
+			continue
+		}
+
+		err := removeIfExists(filePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareImages prepares the shortcut's icon, grid image, and portrait
+// for Steam. The config's image paths are replaced with the prepared
+// images' paths. Problems with the images are returned as warnings.
+func (o *NewShortcutConfig) prepareImages(planOnly bool) []string {
+	var warnings []string
+
+	id := strings.TrimPrefix(o.ShortcutId, managedShortcutIdPrefix)
+
+	images := []struct {
+		filePath            *string
+		spec                imageSpec
+		supportedExtensions []string
+	}{
+		{filePath: &o.IconPath, spec: iconImageSpec, supportedExtensions: steamIconExtensions},
+		{filePath: &o.GridImagePath, spec: gridImageSpec, supportedExtensions: steamImageExtensions},
+		{filePath: &o.PortraitImagePath, spec: portraitImageSpec, supportedExtensions: steamImageExtensions},
+	}
+
+	for _, i := range images {
+		if len(*i.filePath) == 0 {
+			continue
+		}
+
+		cacheDirPath := o.Info.ImageCacheDirPath
+		if len(id) == 0 {
+			cacheDirPath = ""
+		}
+
+		prepared, err := prepareImage(*i.filePath, i.spec, i.supportedExtensions, o.ImageFit,
+			cacheDirPath, id + "-" + i.spec.kind, planOnly)
+		if err != nil {
+			warnings = append(warnings, "failed to prepare " + i.spec.kind + " image - " + err.Error())
+			continue
+		}
+
+		if len(prepared.warning) > 0 {
+			warnings = append(warnings, prepared.warning)
+		}
+
+		*i.filePath = prepared.filePath
+	}
+
+	return warnings
+}
+
+// removeCachedImages removes the shortcut's processed images from
+// the image cache directory.
+func removeCachedImages(info DataInfo, shortcutId string) error {
+	id := strings.TrimPrefix(shortcutId, managedShortcutIdPrefix)
+	if len(info.ImageCacheDirPath) == 0 || len(id) == 0 {
+		return nil
+	}
+
+	return pruneCachedImages(info.ImageCacheDirPath, id, "")
+}
@@ -0,0 +1,111 @@
+package steamw
+
+import (
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestPrepareImage(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-image-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	cacheDirPath := path.Join(dirPath, "cache")
+
+	writeImage := func(filename string, width int, height int) string {
+		img := image.NewNRGBA(image.Rect(0, 0, width, height))
+		img.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+
+		data, err := encodePng(img)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		filePath := path.Join(dirPath, filename)
+
+		err = ioutil.WriteFile(filePath, data, 0600)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		return filePath
+	}
+
+	good := writeImage("good-grid.png", 920, 430)
+
+	prepared, err := prepareImage(good, gridImageSpec, steamImageExtensions, ImageFitLetterbox, cacheDirPath, "x-grid", false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if prepared.filePath != good || len(prepared.warning) > 0 {
+		t.Fatal("An image with the expected aspect ratio should be used as-is - got", prepared)
+	}
+
+	square := writeImage("square-grid.png", 300, 300)
+
+	prepared, err = prepareImage(square, gridImageSpec, steamImageExtensions, ImageFitNone, cacheDirPath, "x-grid", false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if prepared.filePath != square || len(prepared.warning) == 0 {
+		t.Fatal("A badly sized image should only be reported when fit is none - got", prepared)
+	}
+
+	for _, fit := range []ImageFit{ImageFitLetterbox, ImageFitScale} {
+		prepared, err = prepareImage(square, gridImageSpec, steamImageExtensions, fit, cacheDirPath, "x-grid", false)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if path.Dir(prepared.filePath) != cacheDirPath || len(prepared.warning) == 0 {
+			t.Fatal("A badly sized image should be processed into the cache - got", prepared)
+		}
+
+		data, err := ioutil.ReadFile(prepared.filePath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		img, err := decodeImage(data, prepared.filePath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if img.Bounds().Dx() != gridImageSpec.width || img.Bounds().Dy() != gridImageSpec.height {
+			t.Fatal("Processed image has unexpected dimensions", img.Bounds())
+		}
+
+		_, _, _, a := img.At(0, 0).RGBA()
+		if fit == ImageFitLetterbox && a != 0 {
+			t.Fatal("Letterboxed image should have transparent bars")
+		}
+
+		infos, err := ioutil.ReadDir(cacheDirPath)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if len(infos) != 1 {
+			t.Fatal("Older processed images should be removed - found", len(infos), "files")
+		}
+	}
+
+	icon := writeImage("x-icon.png", 32, 16)
+
+	prepared, err = prepareImage(icon, iconImageSpec, steamIconExtensions, ImageFitLetterbox, cacheDirPath, "x-icon", true)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if prepared.filePath != icon || len(prepared.warning) == 0 {
+		t.Fatal("A non-square icon should only be reported when planning - got", prepared)
+	}
+}
@@ -0,0 +1,169 @@
+package steamw
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	convertedImageExtension = ".png"
+	imageDirMode            = 0755
+)
+
+var (
+	// steamImageExtensions are the image file extensions that Steam
+	// can display in the library.
+	steamImageExtensions = []string{".png", ".jpg"}
+
+	// steamIconExtensions are the image file extensions that Steam
+	// can display as a shortcut's icon.
+	steamIconExtensions = []string{".png", ".jpg", ".ico"}
+
+	// installedImageExtensions are the extensions of the image files
+	// that the application may have installed into a grid directory,
+	// including those installed by older versions.
+	installedImageExtensions = []string{".png", ".jpg", ".jpeg"}
+)
+
+// installImage installs an image into a Steam user's grid directory.
+// The installed file's path is destFilePathNoExt followed by the
+// image's extension. Images that Steam cannot display are converted
+// to PNG. Copies of the image with a different extension are removed.
+func installImage(sourceFilePath string, destFilePathNoExt string) error {
+	data, extension, err := steamImage(sourceFilePath, steamImageExtensions)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(path.Dir(destFilePathNoExt), imageDirMode)
+	if err != nil {
+		return err
+	}
+
+	err = atomicfile.WriteFile(destFilePathNoExt + extension, data, defaultShortcutsFileMode)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range installedImageExtensions {
+		if other == extension {
+			continue
+		}
+
+		err := removeIfExists(destFilePathNoExt + other)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeInstalledImage removes an image installed by installImage.
+func removeInstalledImage(destFilePathNoExt string) error {
+	for _, extension := range installedImageExtensions {
+		err := removeIfExists(destFilePathNoExt + extension)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// steamImage reads an image file, converting it to PNG if its extension
+// is not one of the supported extensions. The image's data and its
+// resulting extension are returned.
+func steamImage(sourceFilePath string, supportedExtensions []string) ([]byte, string, error) {
+	extension := imageExtension(sourceFilePath)
+
+	if isOneOf(extension, supportedExtensions) {
+		data, err := ioutil.ReadFile(sourceFilePath)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return data, extension, nil
+	}
+
+	data, err := convertImageToPng(sourceFilePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return data, convertedImageExtension, nil
+}
+
+// imageExtension returns the image file's lowercase extension. JPEG
+// files always have the '.jpg' extension, which is what Steam uses.
+func imageExtension(filePath string) string {
+	extension := strings.ToLower(path.Ext(filePath))
+	if extension == ".jpeg" {
+		return ".jpg"
+	}
+
+	return extension
+}
+
+func convertImageToPng(sourceFilePath string) ([]byte, error) {
+	data, err := ioutil.ReadFile(sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := decodeImage(data, sourceFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodePng(img)
+}
+
+// decodeImage decodes an image file's data. The file's path is used
+// to identify ICO files, which cannot be identified by their data.
+func decodeImage(data []byte, filePath string) (image.Image, error) {
+	var img image.Image
+	var err error
+
+	if imageExtension(filePath) == ".ico" {
+		img, err = decodeIco(bytes.NewReader(data))
+	} else {
+		img, _, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, errors.New("failed to decode image '" + filePath + "' - " + err.Error())
+	}
+
+	return img, nil
+}
+
+func encodePng(img image.Image) ([]byte, error) {
+	buffer := bytes.NewBuffer(nil)
+
+	err := png.Encode(buffer, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func isOneOf(s string, l []string) bool {
+	for i := range l {
+		if l[i] == s {
+			return true
+		}
+	}
+
+	return false
+}
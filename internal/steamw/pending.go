@@ -0,0 +1,179 @@
+package steamw
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+	"github.com/stephen-fox/grundy/internal/results"
+)
+
+const (
+	deferredReason = "Steam is running - the change will be made after Steam exits"
+)
+
+// PendingChange is a change to Steam users' shortcuts that was not made
+// because Steam was running. Exactly one of Update or Delete is set.
+type PendingChange struct {
+	QueuedAt time.Time             `json:"queued_at"`
+	Update   *NewShortcutConfig    `json:"update,omitempty"`
+	Delete   *DeleteShortcutConfig `json:"delete,omitempty"`
+}
+
+// Description returns a human readable description of the change.
+func (o PendingChange) Description() string {
+	if o.Delete != nil {
+		return "delete shortcut for game '" + o.Delete.GameName + "'"
+	}
+
+	return "create or update shortcut for game '" + o.Update.Name + "'"
+}
+
+// GameDirPath returns the path to the game's directory.
+func (o PendingChange) GameDirPath() string {
+	if o.Delete != nil {
+		return o.Delete.GameDirPath
+	}
+
+	return o.Update.GameDirPath
+}
+
+func (o PendingChange) shortcutId() string {
+	if o.Delete != nil {
+		return o.Delete.ShortcutId
+	}
+
+	return o.Update.ShortcutId
+}
+
+// PendingChanges is a queue of shortcut changes waiting for Steam to exit.
+// Steam overwrites its users' shortcuts files when it exits, discarding
+// changes made while it was running.
+//
+// The queue is saved to a file so that it survives restarts of
+// the application.
+type PendingChanges struct {
+	filePath string
+	changes  []PendingChange
+}
+
+// Changes returns the pending changes, oldest first.
+func (o *PendingChanges) Changes() []PendingChange {
+	return o.changes
+}
+
+// IsEmpty returns true if there are no pending changes.
+func (o *PendingChanges) IsEmpty() bool {
+	return len(o.changes) == 0
+}
+
+// Apply makes the pending changes and removes them from the queue.
+// It should only be called when Steam is not running.
+func (o *PendingChanges) Apply(info DataInfo) []results.Result {
+	batch := NewBatch()
+
+	for _, change := range o.changes {
+		if change.Delete != nil {
+			config := *change.Delete
+			config.Info = info
+			batch.DeleteShortcut(config)
+		} else {
+			config := *change.Update
+			config.Info = info
+			batch.CreateOrUpdateShortcut(config)
+		}
+	}
+
+	r := batch.Flush()
+
+	o.changes = nil
+
+	err := o.save()
+	if err != nil {
+		r = append(r, results.NewUpdateShortcutFailed("",
+			"failed to clear pending changes - " + err.Error()))
+	}
+
+	return r
+}
+
+// add queues a change. Older changes to the same shortcut are
+// replaced by the change.
+func (o *PendingChanges) add(change PendingChange) {
+	id := change.shortcutId()
+
+	for i := range o.changes {
+		if o.changes[i].shortcutId() == id {
+			o.changes = append(o.changes[:i], o.changes[i+1:]...)
+			break
+		}
+	}
+
+	o.changes = append(o.changes, change)
+}
+
+// discard removes any changes to the specified shortcuts.
+func (o *PendingChanges) discard(shortcutIds map[string]bool) {
+	var remaining []PendingChange
+
+	for _, change := range o.changes {
+		if !shortcutIds[change.shortcutId()] {
+			remaining = append(remaining, change)
+		}
+	}
+
+	o.changes = remaining
+}
+
+func (o *PendingChanges) save() error {
+	if o.IsEmpty() {
+		err := os.Remove(o.filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	raw, err := json.MarshalIndent(o.changes, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return atomicfile.WriteFile(o.filePath, raw, defaultShortcutsFileMode)
+}
+
+// LoadPendingChanges loads the queue of pending changes from a file.
+// The queue is empty if the file does not exist.
+func LoadPendingChanges(filePath string) (*PendingChanges, error) {
+	pending := &PendingChanges{
+		filePath: filePath,
+	}
+
+	raw, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return pending, nil
+		}
+
+		return pending, err
+	}
+
+	err = json.Unmarshal(raw, &pending.changes)
+	if err != nil {
+		pending.changes = nil
+		return pending, errors.New("failed to parse pending changes file '" + filePath + "' - " + err.Error())
+	}
+
+	for _, change := range pending.changes {
+		if change.Update == nil && change.Delete == nil {
+			pending.changes = nil
+			return pending, errors.New("pending changes file '" + filePath + "' contains an empty change")
+		}
+	}
+
+	return pending, nil
+}
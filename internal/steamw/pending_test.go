@@ -0,0 +1,177 @@
+package steamw
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/steamutil/locations"
+)
+
+func TestPendingChangesSaveAndLoad(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-pending-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	filePath := path.Join(dirPath, "pending.json")
+
+	pending, err := LoadPendingChanges(filePath)
+	if err != nil {
+		t.Fatal("Loading a missing file should not fail - " + err.Error())
+	}
+
+	if !pending.IsEmpty() {
+		t.Fatal("Expected no pending changes")
+	}
+
+	pending.add(PendingChange{
+		Update: &NewShortcutConfig{ShortcutId: "a", Name: "Pikmin"},
+	})
+	pending.add(PendingChange{
+		Update: &NewShortcutConfig{ShortcutId: "b", Name: "Pikmin 2"},
+	})
+	// Supersedes the first change.
+	pending.add(PendingChange{
+		Delete: &DeleteShortcutConfig{ShortcutId: "a", GameName: "Pikmin"},
+	})
+
+	err = pending.save()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	loaded, err := LoadPendingChanges(filePath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	changes := loaded.Changes()
+	if len(changes) != 2 {
+		t.Fatal("Expected 2 pending changes - got", len(changes))
+	}
+
+	if changes[0].Update == nil || changes[0].Update.Name != "Pikmin 2" {
+		t.Fatal("Unexpected first change - " + changes[0].Description())
+	}
+
+	if changes[1].Delete == nil || changes[1].Delete.ShortcutId != "a" {
+		t.Fatal("Unexpected second change - " + changes[1].Description())
+	}
+
+	loaded.discard(map[string]bool{"a": true, "b": true})
+
+	err = loaded.save()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, statErr := os.Stat(filePath)
+	if statErr == nil {
+		t.Fatal("The pending changes file should be removed when it is empty")
+	}
+}
+
+func TestPendingChangesApplyDeferredUpdate(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-pending-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	steamUserId := "123"
+
+	err = os.MkdirAll(path.Join(locations.UserIdDirPath(rootDirPath, steamUserId), "config"), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err := NewSteamDataInfo(rootDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	info.ImageCacheDirPath = path.Join(rootDirPath, "cache")
+
+	data, err := encodePng(image.NewNRGBA(image.Rect(0, 0, 300, 300)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	gridImagePath := path.Join(rootDirPath, "grid.png")
+
+	err = ioutil.WriteFile(gridImagePath, data, 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	exePath := "/opt/Dolphin Emulator/dolphin-emu"
+
+	batch := NewBatch()
+	batch.CreateOrUpdateShortcut(NewShortcutConfig{
+		ShortcutId:    GameShortcutId("/games/Pikmin"),
+		Name:          "Pikmin",
+		ExePath:       exePath,
+		GridImagePath: gridImagePath,
+		GameDirPath:   "/games/Pikmin",
+		Info:          info,
+		ImageFit:      ImageFitLetterbox,
+		Warnings:      []string{"a warning"},
+	})
+
+	pending, err := LoadPendingChanges(path.Join(rootDirPath, "pending.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for _, result := range batch.deferTo(pending) {
+		if result.Outcome() == results.Failed {
+			t.Fatal(result.PrintableResult())
+		}
+	}
+
+	loaded, err := LoadPendingChanges(path.Join(rootDirPath, "pending.json"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	changes := loaded.Changes()
+	if len(changes) != 1 || changes[0].Update == nil {
+		t.Fatal("Expected 1 pending update - got", changes)
+	}
+
+	queued := changes[0].Update
+	if queued.ExePath != exePath || queued.GridImagePath != gridImagePath || len(queued.Warnings) > 0 {
+		t.Fatal("The update should be queued as it was requested - got", *queued)
+	}
+
+	r := loaded.Apply(info)
+	if len(r) != 1 {
+		t.Fatal("Expected 1 result - got", len(r))
+	}
+
+	if r[0].Outcome() == results.Failed {
+		t.Fatal(r[0].PrintableResult())
+	}
+
+	scs, _, err := readShortcutsFile(locations.ShortcutsFilePath(rootDirPath, steamUserId))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(scs) != 1 || scs[0].ExePath != exePath {
+		t.Fatal("Expected the shortcut's executable to be '" + exePath + "' - got", scs)
+	}
+
+	cached, err := ioutil.ReadDir(info.ImageCacheDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(cached) != 1 {
+		t.Fatal("The prepared grid image should remain in the cache - got", len(cached), "files")
+	}
+}
@@ -0,0 +1,123 @@
+package steamw
+
+import (
+	"strings"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/steamutil/locations"
+)
+
+// PlanCreateOrUpdateShortcut reports the changes that CreateOrUpdateShortcut
+// would make for each Steam user without modifying any files.
+func PlanCreateOrUpdateShortcut(config NewShortcutConfig) []results.Result {
+	config.Warnings = append(config.Warnings, config.prepareImages(true)...)
+
+	config.clean()
+
+	var r []results.Result
+
+	for steamUserId := range config.Info.IdsToDirPaths {
+		shortcutsPath := locations.ShortcutsFilePath(config.Info.DataLocations.RootDirPath(), steamUserId)
+
+		current, _, err := readShortcutsFile(shortcutsPath)
+		if err != nil {
+			r = append(r, results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId, err.Error()))
+			continue
+		}
+
+		if !config.Info.IsTargetedUser(steamUserId, config.UserFilters) {
+			_, exists := findManagedShortcut(current, config.ShortcutId, "", "")
+			if exists {
+				r = append(r, results.NewDeleteSteamUserShortcutPlanned(config.Name, steamUserId,
+					"the Steam user is not targeted by the game's settings"))
+			}
+			continue
+		}
+
+		i, exists := findManagedShortcut(current, config.ShortcutId, config.Name, config.ExePath)
+		if !exists && len(config.PreviousName) > 0 {
+			i, exists = findManagedShortcut(current, "", config.PreviousName, config.ExePath)
+		}
+		if exists && current[i].AppName != config.Name {
+			r = append(r, results.NewRenameSteamUserShortcutPlanned(config.Name, steamUserId,
+				"renaming from '" + current[i].AppName + "', " + planDescription(config)))
+		} else if exists {
+			r = append(r, results.NewUpdateSteamUserShortcutPlanned(config.Name, steamUserId,
+				planDescription(config)))
+		} else {
+			r = append(r, results.NewCreateSteamUserShortcutPlanned(config.Name, steamUserId,
+				planDescription(config)))
+		}
+	}
+
+	ids := shortcutIds(config.Name, config.ExePath)
+
+	return results.WithShortcutIds(r, ids.AppIdString(), ids.LegacyGridIdString())
+}
+
+// PlanDeleteShortcut reports the changes that DeleteShortcut would make
+// for each Steam user without modifying any files.
+func PlanDeleteShortcut(config DeleteShortcutConfig) []results.Result {
+	var r []results.Result
+
+	for steamUserId := range config.Info.IdsToDirPaths {
+		if !config.Info.IsTargetedUser(steamUserId, config.UserFilters) {
+			continue
+		}
+
+		shortcutsPath := locations.ShortcutsFilePath(config.Info.DataLocations.RootDirPath(), steamUserId)
+
+		current, _, err := readShortcutsFile(shortcutsPath)
+		if err != nil {
+			r = append(r, results.NewDeleteSteamUserShortcutFailure(config.GameName, steamUserId, err.Error()))
+			continue
+		}
+
+		_, exists := findManagedShortcut(current, config.ShortcutId, config.GameName, config.LauncherExePath)
+		if !exists {
+			r = append(r, results.NewDeleteSteamUserShortcutSkipped(config.GameName, steamUserId,
+				"no matching shortcut was found"))
+			continue
+		}
+
+		r = append(r, results.NewDeleteSteamUserShortcutPlanned(config.GameName, steamUserId,
+			"grid image and artwork will be removed"))
+	}
+
+	return r
+}
+
+func planDescription(config NewShortcutConfig) string {
+	details := []string{
+		"executable: '" + config.ExePath + "'",
+		"launch options: '" + launchOptionsSliceToString(config.LaunchOptions) + "'",
+	}
+
+	if len(config.IconPath) > 0 {
+		details = append(details, "icon: '" + config.IconPath + "'")
+	} else {
+		details = append(details, "icon: none")
+	}
+
+	if len(config.GridImagePath) > 0 {
+		details = append(details, "grid image: '" + config.GridImagePath + "'")
+	} else {
+		details = append(details, "grid image: none (existing image will be removed)")
+	}
+
+	for _, a := range config.artwork() {
+		if len(a.sourceFilePath) > 0 {
+			details = append(details, a.kind + " image: '" + a.sourceFilePath + "'")
+		}
+	}
+
+	if len(config.Tags) > 0 {
+		details = append(details, "categories: '" + strings.Join(config.Tags, "', '") + "'")
+	}
+
+	if len(config.Warnings) > 0 {
+		details = append(details, "warnings: " + strings.Join(config.Warnings, ", "))
+	}
+
+	return strings.Join(details, ", ")
+}
@@ -0,0 +1,101 @@
+package steamw
+
+import (
+	"image"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stephen-fox/grundy/internal/results"
+	"github.com/stephen-fox/steamutil/locations"
+)
+
+func TestPlanDeleteShortcutDescribesDelete(t *testing.T) {
+	rootDirPath, err := ioutil.TempDir("", "grundy-plan-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(rootDirPath)
+
+	steamUserId := "123"
+
+	err = os.MkdirAll(path.Join(locations.UserIdDirPath(rootDirPath, steamUserId), "config"), 0755)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err := NewSteamDataInfo(rootDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	info.ImageCacheDirPath = path.Join(rootDirPath, "cache")
+
+	data, err := encodePng(image.NewNRGBA(image.Rect(0, 0, 460, 215)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	gridImagePath := path.Join(rootDirPath, "grid.png")
+
+	err = ioutil.WriteFile(gridImagePath, data, 0600)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	batch := NewBatch()
+	batch.CreateOrUpdateShortcut(NewShortcutConfig{
+		ShortcutId:    GameShortcutId("/games/Pikmin"),
+		Name:          "Pikmin",
+		ExePath:       "/usr/bin/dolphin",
+		GridImagePath: gridImagePath,
+		GameDirPath:   "/games/Pikmin",
+		Info:          info,
+	})
+
+	for _, result := range batch.Flush() {
+		if result.Outcome() != results.Succeeded {
+			t.Fatal(result.PrintableResult())
+		}
+	}
+
+	gridImages := func() []os.FileInfo {
+		infos, err := ioutil.ReadDir(locations.GridDirPath(rootDirPath, steamUserId))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err.Error())
+		}
+
+		return infos
+	}
+
+	if len(gridImages()) != 1 {
+		t.Fatal("Expected the shortcut's grid image to be installed - got", gridImages())
+	}
+
+	// The game's launcher is not known, as is the case for a game
+	// whose directory was removed.
+	config := DeleteShortcutConfig{
+		ShortcutId:  GameShortcutId("/games/Pikmin"),
+		GameName:    "Pikmin",
+		GameDirPath: "/games/Pikmin",
+		Info:        info,
+	}
+
+	planned := PlanDeleteShortcut(config)
+	if len(planned) != 1 || planned[0].Outcome() != results.Planned ||
+		planned[0].Reason() != "grid image and artwork will be removed" {
+		t.Fatal("Unexpected plan -", planned)
+	}
+
+	batch.DeleteShortcut(config)
+
+	for _, result := range batch.Flush() {
+		if result.Outcome() != results.Succeeded {
+			t.Fatal(result.PrintableResult())
+		}
+	}
+
+	if len(gridImages()) != 0 {
+		t.Fatal("The plan said that the grid image would be removed, but it still exists")
+	}
+}
@@ -0,0 +1,16 @@
+package steamw
+
+const (
+	steamExitUrl = "steam://exit"
+)
+
+// IsSteamRunning returns true if the Steam client is running.
+func IsSteamRunning() (bool, error) {
+	return isSteamRunning()
+}
+
+// RequestSteamShutdown asks the Steam client to exit. It does not wait
+// for the client to exit.
+func RequestSteamShutdown() error {
+	return requestSteamShutdown()
+}
@@ -0,0 +1,36 @@
+package steamw
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+const (
+	steamProcessName = "steam_osx"
+)
+
+func isSteamRunning() (bool, error) {
+	err := exec.Command("pgrep", "-x", steamProcessName).Run()
+	if err != nil {
+		// pgrep exits with status 1 when no process matched.
+		exitErr, ok := err.(*exec.ExitError)
+		if ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+func requestSteamShutdown() error {
+	output, err := exec.Command("open", steamExitUrl).CombinedOutput()
+	if err != nil {
+		return errors.New("failed to ask Steam to exit - " + err.Error() + " - " +
+			strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
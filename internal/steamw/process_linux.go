@@ -0,0 +1,59 @@
+package steamw
+
+import (
+	"errors"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	steamProcessName = "steam"
+)
+
+func isSteamRunning() (bool, error) {
+	infos, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return false, err
+	}
+
+	for _, info := range infos {
+		_, err := strconv.Atoi(info.Name())
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		// The process may have exited since the directory was listed.
+		comm, err := ioutil.ReadFile(path.Join("/proc", info.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+
+		if strings.TrimSpace(string(comm)) == steamProcessName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func requestSteamShutdown() error {
+	var cmd *exec.Cmd
+
+	_, err := exec.LookPath(steamProcessName)
+	if err == nil {
+		cmd = exec.Command(steamProcessName, "-shutdown")
+	} else {
+		cmd = exec.Command("xdg-open", steamExitUrl)
+	}
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return errors.New("failed to ask Steam to exit - " + err.Error() + " - " +
+			strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
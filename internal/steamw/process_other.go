@@ -0,0 +1,15 @@
+// +build !linux,!darwin,!windows
+
+package steamw
+
+import (
+	"errors"
+)
+
+func isSteamRunning() (bool, error) {
+	return false, nil
+}
+
+func requestSteamShutdown() error {
+	return errors.New("asking Steam to exit is not supported on this platform")
+}
@@ -0,0 +1,31 @@
+package steamw
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+const (
+	steamProcessName = "steam.exe"
+)
+
+func isSteamRunning() (bool, error) {
+	output, err := exec.Command("tasklist", "/FI", "IMAGENAME eq " + steamProcessName,
+		"/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return false, err
+	}
+
+	return strings.Contains(strings.ToLower(string(output)), "\"" + steamProcessName + "\""), nil
+}
+
+func requestSteamShutdown() error {
+	output, err := exec.Command("cmd", "/C", "start", "", steamExitUrl).CombinedOutput()
+	if err != nil {
+		return errors.New("failed to ask Steam to exit - " + err.Error() + " - " +
+			strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
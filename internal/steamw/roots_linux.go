@@ -0,0 +1,38 @@
+package steamw
+
+import (
+	"os"
+	"path"
+	"strings"
+)
+
+const (
+	NativeRoot  RootSource = "native install"
+	FlatpakRoot RootSource = "Flatpak install"
+	SnapRoot    RootSource = "Snap install"
+)
+
+func platformRootCandidates() []rootCandidate {
+	homePath := os.Getenv("HOME")
+	if len(strings.TrimSpace(homePath)) == 0 {
+		return []rootCandidate{}
+	}
+
+	dataHomePath := os.Getenv("XDG_DATA_HOME")
+	if len(strings.TrimSpace(dataHomePath)) == 0 {
+		dataHomePath = path.Join(homePath, ".local/share")
+	}
+
+	flatpakHomePath := path.Join(homePath, ".var/app/com.valvesoftware.Steam")
+	snapHomePath := path.Join(homePath, "snap/steam/common")
+
+	return []rootCandidate{
+		{dirPath: path.Join(homePath, ".steam/steam"), source: NativeRoot},
+		{dirPath: path.Join(homePath, ".steam/root"), source: NativeRoot},
+		{dirPath: path.Join(dataHomePath, "Steam"), source: NativeRoot},
+		{dirPath: path.Join(flatpakHomePath, ".local/share/Steam"), source: FlatpakRoot},
+		{dirPath: path.Join(flatpakHomePath, ".steam/steam"), source: FlatpakRoot},
+		{dirPath: path.Join(snapHomePath, ".local/share/Steam"), source: SnapRoot},
+		{dirPath: path.Join(snapHomePath, ".steam/steam"), source: SnapRoot},
+	}
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package steamw
+
+func platformRootCandidates() []rootCandidate {
+	return []rootCandidate{}
+}
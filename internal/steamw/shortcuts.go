@@ -1,14 +1,18 @@
 package steamw
 
 import (
-	"bytes"
+	"io/ioutil"
 	"os"
 	"path"
+	"runtime"
 	"strings"
+	"time"
 
+	"github.com/stephen-fox/grundy/internal/cmdline"
 	"github.com/stephen-fox/grundy/internal/results"
 	"github.com/stephen-fox/steamutil/grid"
 	"github.com/stephen-fox/steamutil/locations"
+	"github.com/stephen-fox/steamutil/naming"
 	"github.com/stephen-fox/steamutil/shortcuts"
 )
 
@@ -17,233 +21,400 @@ const (
 )
 
 type NewShortcutConfig struct {
-	Name          string
-	LaunchOptions []string
-	ExePath       string
-	IconPath      string
-	GridImagePath string
-	Tags          []string
-	Info          DataInfo
-	Warnings      []string
-	startDir      string
+	ShortcutId        string
+	Name              string
+	PreviousName      string
+	LaunchOptions     []string
+	ExePath           string
+	IconPath          string
+	GridImagePath     string
+	PortraitImagePath string
+	HeroImagePath     string
+	LogoImagePath     string
+	Tags              []string
+	GameDirPath       string
+	UserFilters       []UserFilter
+	Info              DataInfo `json:"-"`
+	ImageFit          ImageFit
+	Warnings          []string `json:"-"`
+	startDir          string
 }
 
+// clean double quotes the executable, icon, and start directory paths,
+// as Steam does when it adds a non-Steam game. The start directory is
+// derived from the executable path before it is quoted.
 func (o *NewShortcutConfig) clean() {
+	o.startDir = doubleQuoteIfNeeded(path.Dir(o.ExePath))
 	o.ExePath = doubleQuoteIfNeeded(o.ExePath)
 	o.IconPath = doubleQuoteIfNeeded(o.IconPath)
-	o.startDir = doubleQuoteIfNeeded(path.Dir(o.ExePath))
 }
 
 // TODO: Clean?
 type DeleteShortcutConfig struct {
-	SkipGridImageDelete bool
-	LauncherExePath     string
-	GameName            string
-	Info                DataInfo
+	ShortcutId      string
+	LauncherExePath string
+	GameName        string
+	GameDirPath     string
+	UserFilters     []UserFilter
+	Info            DataInfo `json:"-"`
 }
 
-type deleteShortcutResult struct {
-	wasDeleted bool
-}
+// CreateOrUpdateShortcut creates or updates the game's shortcut for each
+// targeted Steam user. The game's shortcut is removed from Steam users
+// that are not targeted. The changes are saved when the batch is flushed.
+func (o *Batch) CreateOrUpdateShortcut(config NewShortcutConfig) {
+	// The change is queued as it was requested, as images are prepared
+	// and paths are quoted again if the change is deferred and applied.
+	queued := config
+	queued.Warnings = nil
 
-func CreateOrUpdateShortcut(config NewShortcutConfig) []results.Result {
-	config.clean()
+	o.changes = append(o.changes, PendingChange{
+		QueuedAt: time.Now(),
+		Update:   &queued,
+	})
 
-	var r []results.Result
+	config.Warnings = append(config.Warnings, config.prepareImages(false)...)
+
+	config.clean()
 
 	for steamUserId := range config.Info.IdsToDirPaths {
-		shortcutsPath := locations.ShortcutsFilePath(config.Info.DataLocations.RootDirPath(), steamUserId)
+		if !config.Info.IsTargetedUser(steamUserId, config.UserFilters) {
+			o.removeUntargetedShortcut(config, steamUserId)
+			continue
+		}
 
-		fileUpdateResult, err := createOrUpdateShortcut(config, shortcutsPath)
+		current, err := o.userShortcuts(config.Info, steamUserId)
 		if err != nil {
-			r = append(r, results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId, err.Error()))
+			o.addResult(steamUserId, config.GameDirPath,
+				results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId, err.Error()))
 			continue
 		}
 
-		err = addOrRemoveShortcutGridImage(config, steamUserId)
+		fileUpdateResult, previous := current.createOrUpdate(config)
+
+		o.addPending(pendingResult{
+			steamUserId: steamUserId,
+			gameDirPath: config.GameDirPath,
+			ids:         shortcutIds(config.Name, config.ExePath),
+			complete:    completeCreateOrUpdateFunc(config, steamUserId, fileUpdateResult, previous),
+			fail:        failedUpdateFunc(config.Name, steamUserId),
+			deferred:    deferredUpdateFunc(config.Name, steamUserId),
+		})
+	}
+}
+
+// completeCreateOrUpdateFunc returns a function that updates the game's
+// grid image once the Steam user's shortcuts file has been saved.
+func completeCreateOrUpdateFunc(config NewShortcutConfig, steamUserId string, fileUpdateResult shortcuts.UpdateResult, previous shortcuts.Shortcut) func() results.Result {
+	return func() results.Result {
+		wasRenamed := fileUpdateResult == shortcuts.UpdatedEntry && previous.AppName != config.Name
+
+		var warnings []string
+		warnings = append(warnings, config.Warnings...)
+
+		if wasRenamed {
+			err := moveShortcutGridImages(config.Info, steamUserId,
+				previous.AppName, doubleQuoteIfNeeded(previous.ExePath), config.Name, config.ExePath)
+			if err != nil {
+				warnings = append(warnings, "failed to move existing grid image - " + err.Error())
+			}
+
+			err = moveShortcutArtwork(config.Info.DataLocations, steamUserId,
+				previous.AppName, doubleQuoteIfNeeded(previous.ExePath), config.Name, config.ExePath)
+			if err != nil {
+				warnings = append(warnings, "failed to move existing artwork - " + err.Error())
+			}
+		}
+
+		err := addOrRemoveShortcutGridImage(config, steamUserId)
 		if err != nil {
-			r = append(r, results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId, err.Error()))
-			continue
+			return results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId, err.Error())
 		}
 
-		var ur results.Result
+		err = addOrRemoveShortcutArtwork(config, steamUserId)
+		if err != nil {
+			return results.NewUpdateSteamUserShortcutFailed(config.Name, steamUserId,
+				"failed to update artwork - " + err.Error())
+		}
 
-		if len(config.Warnings) == 0 {
-			switch fileUpdateResult {
-			case shortcuts.UpdatedEntry:
-				ur = results.NewUpdateShortcutSuccess(config.Name)
-			default:
-				ur = results.NewCreateShortcutSuccess(config.Name)
+		if wasRenamed {
+			reason := "renamed from '" + previous.AppName + "'"
+			if len(warnings) == 0 {
+				return results.NewRenameSteamUserShortcutSuccess(config.Name, steamUserId, reason)
 			}
-		} else {
-			ur = results.NewCreateShortcutSuccessWithWarnings(config.Name,
-				strings.Join(config.Warnings, ", "))
+
+			return results.NewRenameSteamUserShortcutSuccessWarning(config.Name, steamUserId,
+				reason + " - " + strings.Join(warnings, ", "))
 		}
 
-		r = append(r, ur)
+		if fileUpdateResult == shortcuts.UpdatedEntry {
+			if len(warnings) == 0 {
+				return results.NewUpdateSteamUserShortcutSuccess(config.Name, steamUserId)
+			}
+
+			return results.NewUpdateSteamUserShortcutSuccessWarning(config.Name, steamUserId,
+				strings.Join(warnings, ", "))
+		}
+
+		if len(warnings) == 0 {
+			return results.NewCreateSteamUserShortcutSuccess(config.Name, steamUserId)
+		}
+
+		return results.NewCreateSteamUserShortcutSuccessWarning(config.Name, steamUserId,
+			strings.Join(warnings, ", "))
 	}
+}
 
-	return r
+func failedUpdateFunc(gameName string, steamUserId string) func(string) results.Result {
+	return func(reason string) results.Result {
+		return results.NewUpdateSteamUserShortcutFailed(gameName, steamUserId, reason)
+	}
 }
 
-func createOrUpdateShortcut(config NewShortcutConfig, shortcutsFilePath string) (shortcuts.UpdateResult, error) {
-	onMatch := func(name string, matched *shortcuts.Shortcut) {
-		matched.StartDir = config.startDir
-		matched.ExePath = config.ExePath
-		matched.LaunchOptions = launchOptionsSliceToString(config.LaunchOptions)
-		matched.IconPath = config.IconPath
-		matched.Tags = config.Tags
+func deferredUpdateFunc(gameName string, steamUserId string) func(string) results.Result {
+	return func(reason string) results.Result {
+		return results.NewUpdateSteamUserShortcutDeferred(gameName, steamUserId, reason)
 	}
+}
 
-	noMatch := func(name string) (shortcuts.Shortcut, bool) {
-		return shortcuts.Shortcut{
-			AppName:       config.Name,
-			ExePath:       config.ExePath,
-			StartDir:      config.startDir,
-			IconPath:      config.IconPath,
-			LaunchOptions: launchOptionsSliceToString(config.LaunchOptions),
-			Tags:          config.Tags,
-		}, false
+// removeUntargetedShortcut removes the game's shortcut from a Steam user
+// that is no longer targeted by the game. Only shortcuts with the
+// game's identity are removed.
+func (o *Batch) removeUntargetedShortcut(config NewShortcutConfig, steamUserId string) {
+	current, err := o.userShortcuts(config.Info, steamUserId)
+	if err != nil {
+		o.addResult(steamUserId, config.GameDirPath,
+			results.NewDeleteSteamUserShortcutFailure(config.Name, steamUserId, err.Error()))
+		return
 	}
 
-	createOrUpdateConfig := shortcuts.CreateOrUpdateConfig{
-		MatchName: config.Name,
-		Path:      shortcutsFilePath,
-		Mode:      defaultShortcutsFileMode,
-		OnMatch:   onMatch,
-		NoMatch:   noMatch,
+	removed, wasDeleted := current.delete(config.ShortcutId, "", "")
+	if !wasDeleted {
+		return
 	}
 
-	result, err := shortcuts.CreateOrUpdateVdfV1File(createOrUpdateConfig)
-	if err != nil {
-		return result, err
+	imageDetails := removedShortcutImageDetails(config.Info, steamUserId, removed)
+
+	o.addPending(pendingResult{
+		steamUserId: steamUserId,
+		gameDirPath: config.GameDirPath,
+		ids:         shortcutIds(imageDetails.GameName, imageDetails.GameExecutablePath),
+		complete:    completeDeleteFunc(config.Name, steamUserId, imageDetails,
+			"the Steam user is not targeted by the game's settings"),
+		fail:        failedDeleteFunc(config.Name, steamUserId),
+		deferred:    deferredDeleteFunc(config.Name, steamUserId),
+	})
+}
+
+// createOrUpdate creates or updates the game's shortcut. The
+// shortcut's state prior to being updated is returned if it already existed.
+func (o *userShortcuts) createOrUpdate(config NewShortcutConfig) (shortcuts.UpdateResult, shortcuts.Shortcut) {
+	result := shortcuts.AddedNewEntry
+	var previous shortcuts.Shortcut
+
+	i, matched := findManagedShortcut(o.shortcuts, config.ShortcutId, config.Name, config.ExePath)
+	if !matched && len(config.PreviousName) > 0 {
+		i, matched = findManagedShortcut(o.shortcuts, "", config.PreviousName, config.ExePath)
+	}
+	if matched {
+		result = shortcuts.UpdatedEntry
+		previous = o.shortcuts[i]
+		applyShortcutConfig(config, &o.shortcuts[i])
+	} else {
+		newShortcut := shortcuts.Shortcut{
+			Id: len(o.shortcuts),
+		}
+		applyShortcutConfig(config, &newShortcut)
+		o.shortcuts = append(o.shortcuts, newShortcut)
 	}
 
-	return result, nil
+	o.changed = true
+
+	if !o.existed && result == shortcuts.AddedNewEntry {
+		return shortcuts.CreatedNewFile, previous
+	}
+
+	return result, previous
+}
+
+func applyShortcutConfig(config NewShortcutConfig, s *shortcuts.Shortcut) {
+	s.AppName = config.Name
+	s.ExePath = config.ExePath
+	s.StartDir = config.startDir
+	s.IconPath = config.IconPath
+	s.ShortcutPath = config.ShortcutId
+	s.LaunchOptions = launchOptionsSliceToString(config.LaunchOptions)
+	s.Tags = config.Tags
 }
 
 func addOrRemoveShortcutGridImage(config NewShortcutConfig, steamUserId string) error {
-	imageDetails := grid.ImageDetails{
-		DataVerifier:       config.Info.DataLocations,
-		OwnerUserId:        steamUserId,
-		GameName:           config.Name,
-		GameExecutablePath: config.ExePath,
+	if len(config.GridImagePath) == 0 {
+		return removeShortcutGridImage(grid.ImageDetails{
+			DataVerifier:       config.Info.DataLocations,
+			OwnerUserId:        steamUserId,
+			GameName:           config.Name,
+			GameExecutablePath: config.ExePath,
+		})
 	}
 
-	if len(config.GridImagePath) == 0 {
-		removeConfig := grid.RemoveConfig{
-			TargetDetails: imageDetails,
-		}
+	gridDirPath := locations.GridDirPath(config.Info.DataLocations.RootDirPath(), steamUserId)
 
-		// TODO: Should this return an error? Perhaps the grid
-		//  image never existed in the first place?
-		err := grid.RemoveImage(removeConfig)
-		if err != nil {
-			return err
+	return installImage(config.GridImagePath,
+		path.Join(gridDirPath, naming.LegacyNonSteamGameId(config.Name, config.ExePath)))
+}
+
+// moveShortcutGridImages renames any grid images belonging to the old
+// shortcut name and executable so that they belong to the new ones.
+func moveShortcutGridImages(info DataInfo, steamUserId string, oldName string, oldExePath string, newName string, newExePath string) error {
+	gridDirPath, _, err := info.DataLocations.GridDirPath(steamUserId)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
 		}
 
-		return nil
+		return err
 	}
 
-	gridAddConfig := grid.AddConfig{
-		ImageSourcePath:   config.GridImagePath,
-		ResultDetails:     imageDetails,
-		OverwriteExisting: true,
-	}
+	oldId := naming.LegacyNonSteamGameId(oldName, oldExePath)
+	newId := naming.LegacyNonSteamGameId(newName, newExePath)
 
-	err := grid.AddImage(gridAddConfig)
+	infos, err := ioutil.ReadDir(gridDirPath)
 	if err != nil {
 		return err
 	}
 
+	for _, fileInfo := range infos {
+		if fileInfo.IsDir() || !strings.HasPrefix(fileInfo.Name(), oldId) {
+			continue
+		}
+
+		err := os.Rename(path.Join(gridDirPath, fileInfo.Name()),
+			path.Join(gridDirPath, newId + strings.TrimPrefix(fileInfo.Name(), oldId)))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func DeleteShortcut(config DeleteShortcutConfig) []results.Result {
-	var r []results.Result
+// DeleteShortcut deletes the game's shortcut for each targeted Steam user.
+// The changes are saved when the batch is flushed.
+func (o *Batch) DeleteShortcut(config DeleteShortcutConfig) {
+	o.changes = append(o.changes, PendingChange{
+		QueuedAt: time.Now(),
+		Delete:   &config,
+	})
 
 	for steamUserId := range config.Info.IdsToDirPaths {
-		shortcutsPath := locations.ShortcutsFilePath(config.Info.DataLocations.RootDirPath(), steamUserId)
+		if !config.Info.IsTargetedUser(steamUserId, config.UserFilters) {
+			continue
+		}
 
-		delResult, err := deleteShortcut(config, shortcutsPath)
+		current, err := o.userShortcuts(config.Info, steamUserId)
 		if err != nil {
-			r = append(r, results.NewDeleteSteamUserShortcutFailure(config.GameName, steamUserId, err.Error()))
+			o.addResult(steamUserId, config.GameDirPath,
+				results.NewDeleteSteamUserShortcutFailure(config.GameName, steamUserId, err.Error()))
 			continue
 		}
 
-		if !delResult.wasDeleted {
-			r = append(r, results.NewDeleteSteamUserShortcutSkipped(config.GameName, steamUserId,
-				"no matching shortcut was found"))
+		removed, wasDeleted := current.delete(config.ShortcutId, config.GameName, config.LauncherExePath)
+		if !wasDeleted {
+			o.addResult(steamUserId, config.GameDirPath,
+				results.NewDeleteSteamUserShortcutSkipped(config.GameName, steamUserId,
+					"no matching shortcut was found"))
 			continue
 		}
 
-		imageDetails := grid.ImageDetails{
-			DataVerifier:       config.Info.DataLocations,
-			OwnerUserId:        steamUserId,
-			GameExecutablePath: config.LauncherExePath,
-			GameName:           config.GameName,
-		}
+		imageDetails := removedShortcutImageDetails(config.Info, steamUserId, removed)
+
+		o.addPending(pendingResult{
+			steamUserId: steamUserId,
+			gameDirPath: config.GameDirPath,
+			ids:         shortcutIds(imageDetails.GameName, imageDetails.GameExecutablePath),
+			complete:    completeDeleteFunc(config.GameName, steamUserId, imageDetails, ""),
+			fail:        failedDeleteFunc(config.GameName, steamUserId),
+			deferred:    deferredDeleteFunc(config.GameName, steamUserId),
+		})
+	}
+	// Leftover processed images are harmless, so failing to
+	// remove them is not reported.
+	removeCachedImages(config.Info, config.ShortcutId)
+}
+
+// removedShortcutImageDetails returns the details of a deleted shortcut's
+// grid image. The shortcut's name and executable are used rather than the
+// game's, as the game may have changed since the shortcut was saved.
+func removedShortcutImageDetails(info DataInfo, steamUserId string, removed shortcuts.Shortcut) grid.ImageDetails {
+	return grid.ImageDetails{
+		DataVerifier:       info.DataLocations,
+		OwnerUserId:        steamUserId,
+		GameExecutablePath: doubleQuoteIfNeeded(removed.ExePath),
+		GameName:           removed.AppName,
+	}
+}
 
-		err = removeShortcutGridImage(imageDetails)
+// completeDeleteFunc returns a function that removes the game's grid
+// image and artwork once the Steam user's shortcuts file has been saved.
+func completeDeleteFunc(gameName string, steamUserId string, imageDetails grid.ImageDetails, reason string) func() results.Result {
+	return func() results.Result {
+		var warning string
+
+		err := removeShortcutGridImage(imageDetails)
 		if err != nil {
-			r = append(r, results.NewDeleteSteamUserShortcutSuccessWarning(config.GameName,
-				steamUserId, "failed to delete game grid image - " + err.Error()))
-			continue
+			warning = "failed to delete game grid image - " + err.Error()
 		}
 
-		r = append(r, results.NewDeleteSteamUserShortcutSuccess(config.GameName, steamUserId, ""))
-	}
+		err = removeShortcutArtwork(imageDetails.DataVerifier, steamUserId,
+			imageDetails.GameName, imageDetails.GameExecutablePath)
+		if err != nil && len(warning) == 0 {
+			warning = "failed to delete game artwork - " + err.Error()
+		}
 
-	return r
+		if len(warning) > 0 {
+			if len(reason) > 0 {
+				warning = reason + " - " + warning
+			}
+
+			return results.NewDeleteSteamUserShortcutSuccessWarning(gameName, steamUserId, warning)
+		}
+
+		return results.NewDeleteSteamUserShortcutSuccess(gameName, steamUserId, reason)
+	}
 }
 
-// TODO: This whole operation is IO bound - not good.
-//  This needs to be refactored to provide the data set,
-//  and then return the modified data set, leaving the
-//  IO work to the most minimal amount possible.
-func deleteShortcut(config DeleteShortcutConfig, shortcutsFilePath string) (deleteShortcutResult, error) {
-	f, err := os.OpenFile(shortcutsFilePath, os.O_RDWR, defaultShortcutsFileMode)
-	if err != nil {
-		return deleteShortcutResult{}, err
+func failedDeleteFunc(gameName string, steamUserId string) func(string) results.Result {
+	return func(reason string) results.Result {
+		return results.NewDeleteSteamUserShortcutFailure(gameName, steamUserId, reason)
 	}
-	defer f.Close()
+}
 
-	currentShortcuts, err := shortcuts.ReadVdfV1(f)
-	if err != nil {
-		return deleteShortcutResult{}, err
+func deferredDeleteFunc(gameName string, steamUserId string) func(string) results.Result {
+	return func(reason string) results.Result {
+		return results.NewDeleteSteamUserShortcutDeferred(gameName, steamUserId, reason)
 	}
+}
 
-	_, err = f.Seek(0, 0)
-	if err != nil {
-		return deleteShortcutResult{}, err
+// delete removes the shortcut with the specified identity, or the legacy
+// shortcut with the specified name and executable. The removed shortcut
+// is returned. It returns false if no shortcut matched.
+func (o *userShortcuts) delete(id string, legacyName string, legacyExePath string) (shortcuts.Shortcut, bool) {
+	matchIndex, matched := findManagedShortcut(o.shortcuts, id, legacyName, legacyExePath)
+	if !matched {
+		return shortcuts.Shortcut{}, false
 	}
 
-	result := deleteShortcutResult{}
+	removed := o.shortcuts[matchIndex]
 
-	// Based on work by "tomasz":
-	// https://stackoverflow.com/a/20551116
-	i := 0
-	for _, sc := range currentShortcuts {
-		if sc.AppName == config.GameName {
-			result.wasDeleted = true
-			continue
-		}
-		currentShortcuts[i] = sc
-		i++
-	}
-	currentShortcuts = currentShortcuts[:i]
+	o.shortcuts = append(o.shortcuts[:matchIndex], o.shortcuts[matchIndex+1:]...)
 
-	err = f.Truncate(0)
-	if err != nil {
-		return deleteShortcutResult{}, err
+	for i := range o.shortcuts {
+		o.shortcuts[i].Id = i
 	}
 
-	err = shortcuts.WriteVdfV1(currentShortcuts, f)
-	if err != nil {
-		return deleteShortcutResult{}, err
-	}
+	o.changed = true
 
-	return result, nil
+	return removed, true
 }
 
 func removeShortcutGridImage(imageDetails grid.ImageDetails) error {
@@ -253,21 +424,21 @@ func removeShortcutGridImage(imageDetails grid.ImageDetails) error {
 
 	err := grid.RemoveImage(removeConfig)
 	if err != nil {
+		// There is nothing to remove if the user has no grid directory.
+		if os.IsNotExist(err) {
+			return nil
+		}
+
 		return err
 	}
 
 	return nil
 }
 
+// launchOptionsSliceToString joins the launch options into a command line
+// that is quoted for the current operating system.
 func launchOptionsSliceToString(options []string) string {
-	buffer := bytes.NewBuffer(nil)
-
-	for i := range options {
-		buffer.WriteString(options[i])
-		buffer.WriteString(" ")
-	}
-
-	return buffer.String()
+	return cmdline.Join(options, runtime.GOOS)
 }
 
 func doubleQuoteIfNeeded(s string) string {
@@ -0,0 +1,206 @@
+package steamw
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	loginUsersFileSubPath = "config/loginusers.vdf"
+	steamId64Base         = 76561197960265728
+)
+
+// UserFilter restricts which Steam users an operation applies to.
+//
+// Each entry can be a Steam user ID (the name of the user's 'userdata'
+// directory), a 64-bit Steam ID, or an account or persona name.
+type UserFilter struct {
+	// Allowed is the list of Steam users to include. All users are
+	// included if the list is empty.
+	Allowed []string
+
+	// Denied is the list of Steam users to exclude. Denied users
+	// are always excluded.
+	Denied []string
+}
+
+func (o UserFilter) includes(userId string, aliases []string) bool {
+	if matchesUser(o.Denied, userId, aliases) {
+		return false
+	}
+
+	if len(o.Allowed) == 0 {
+		return true
+	}
+
+	return matchesUser(o.Allowed, userId, aliases)
+}
+
+func matchesUser(entries []string, userId string, aliases []string) bool {
+	for _, entry := range entries {
+		if entry == userId {
+			return true
+		}
+
+		for _, alias := range aliases {
+			if strings.EqualFold(entry, alias) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsTargetedUser returns true if the Steam user is included by all of
+// the provided UserFilter.
+func (o DataInfo) IsTargetedUser(userId string, filters []UserFilter) bool {
+	for _, filter := range filters {
+		if !filter.includes(userId, o.IdsToAliases[userId]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadUserIdsToAliases maps Steam user IDs to their 64-bit Steam IDs,
+// account names, and persona names using Steam's login users file.
+func loadUserIdsToAliases(rootDirPath string) (map[string][]string, error) {
+	idsToAliases := make(map[string][]string)
+
+	f, err := os.Open(path.Join(rootDirPath, loginUsersFileSubPath))
+	if err != nil {
+		return idsToAliases, err
+	}
+	defer f.Close()
+
+	root, err := parseTextVdf(bufio.NewReader(f))
+	if err != nil {
+		return idsToAliases, err
+	}
+
+	users, _ := root["users"].(map[string]interface{})
+
+	for steamId64, raw := range users {
+		id64, err := strconv.ParseUint(steamId64, 10, 64)
+		if err != nil || id64 < steamId64Base {
+			continue
+		}
+
+		userId := strconv.FormatUint(id64 - steamId64Base, 10)
+		aliases := []string{steamId64}
+
+		details, _ := raw.(map[string]interface{})
+		for _, k := range []string{"AccountName", "PersonaName"} {
+			v, ok := details[k].(string)
+			if ok && len(v) > 0 {
+				aliases = append(aliases, v)
+			}
+		}
+
+		idsToAliases[userId] = aliases
+	}
+
+	return idsToAliases, nil
+}
+
+// parseTextVdf parses Valve's text key-value format. Values are either
+// strings or nested maps.
+func parseTextVdf(r *bufio.Reader) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+
+	for {
+		k, err := nextVdfToken(r)
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+
+		if k == "}" {
+			return result, nil
+		}
+
+		v, err := nextVdfToken(r)
+		if err != nil {
+			return result, errors.New("unexpected end of data after key '" + k + "'")
+		}
+
+		if v == "{" {
+			nested, err := parseTextVdf(r)
+			if err != nil {
+				return result, err
+			}
+
+			result[k] = nested
+		} else {
+			result[k] = v
+		}
+	}
+}
+
+func nextVdfToken(r *bufio.Reader) (string, error) {
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{', '}':
+			return string(c), nil
+		case '/':
+			// Skip '//' comments.
+			_, err := r.ReadString('\n')
+			if err != nil {
+				return "", err
+			}
+			continue
+		case '"':
+			return readVdfQuotedString(r)
+		}
+
+		return "", errors.New("unexpected character '" + string(c) + "'")
+	}
+}
+
+func readVdfQuotedString(r *bufio.Reader) (string, error) {
+	var builder strings.Builder
+
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+
+		switch c {
+		case '"':
+			return builder.String(), nil
+		case '\\':
+			escaped, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			default:
+				builder.WriteByte(escaped)
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+}
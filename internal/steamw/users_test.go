@@ -0,0 +1,76 @@
+package steamw
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseTextVdf(t *testing.T) {
+	raw := `"users"
+{
+	// A comment.
+	"76561197960266184"
+	{
+		"AccountName"		"kiddo"
+		"PersonaName"		"The \"Kid\""
+	}
+}
+`
+
+	root, err := parseTextVdf(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	users, ok := root["users"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Missing users object")
+	}
+
+	user, ok := users["76561197960266184"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Missing user object")
+	}
+
+	if user["AccountName"] != "kiddo" {
+		t.Fatal("Unexpected account name -", user["AccountName"])
+	}
+
+	if user["PersonaName"] != "The \"Kid\"" {
+		t.Fatal("Unexpected persona name -", user["PersonaName"])
+	}
+}
+
+func TestDataInfoIsTargetedUser(t *testing.T) {
+	info := DataInfo{
+		IdsToAliases: map[string][]string{
+			"456": {"76561197960266184", "kiddo", "Kid"},
+		},
+	}
+
+	if !info.IsTargetedUser("456", nil) {
+		t.Fatal("Users should be targeted when there are no filters")
+	}
+
+	if info.IsTargetedUser("456", []UserFilter{{Denied: []string{"KIDDO"}}}) {
+		t.Fatal("Denied account name should not be targeted")
+	}
+
+	if !info.IsTargetedUser("123", []UserFilter{{Denied: []string{"kiddo"}}}) {
+		t.Fatal("Users that are not denied should be targeted")
+	}
+
+	filters := []UserFilter{
+		{Allowed: []string{"123", "456"}},
+		{Allowed: []string{"123"}},
+	}
+
+	if info.IsTargetedUser("456", filters) {
+		t.Fatal("Users must be allowed by every filter")
+	}
+
+	if !info.IsTargetedUser("123", filters) {
+		t.Fatal("User allowed by every filter should be targeted")
+	}
+}
@@ -0,0 +1,187 @@
+package steamw
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+	"github.com/stephen-fox/steamutil/locations"
+	"github.com/stephen-fox/steamutil/shortcuts"
+)
+
+const (
+	maxShortcutsBackups    = 10
+	shortcutsBackupPrefix  = "shortcuts-"
+	shortcutsBackupSuffix  = ".vdf"
+	shortcutsBackupTimeFmt = "20060102-150405.000000000"
+	backupsDirMode         = 0755
+)
+
+// readShortcutsFile reads a shortcuts file. An empty list is returned if
+// the file does not exist.
+func readShortcutsFile(shortcutsFilePath string) ([]shortcuts.Shortcut, bool, error) {
+	f, err := os.Open(shortcutsFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []shortcuts.Shortcut{}, false, nil
+		}
+
+		return nil, false, err
+	}
+	defer f.Close()
+
+	scs, err := shortcuts.ReadVdfV1(f)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return scs, true, nil
+}
+
+// writeShortcutsFile replaces the Steam user's shortcuts file with the
+// provided shortcuts. The existing file is backed up first.
+func writeShortcutsFile(info DataInfo, steamUserId string, scs []shortcuts.Shortcut) error {
+	buffer := bytes.NewBuffer(nil)
+
+	err := shortcuts.WriteVdfV1(scs, buffer)
+	if err != nil {
+		return err
+	}
+
+	return replaceShortcutsFile(info, steamUserId, buffer.Bytes())
+}
+
+func replaceShortcutsFile(info DataInfo, steamUserId string, data []byte) error {
+	shortcutsFilePath := locations.ShortcutsFilePath(info.DataLocations.RootDirPath(), steamUserId)
+
+	if len(info.BackupsDirPath) > 0 {
+		err := backupShortcutsFile(shortcutsFilePath, path.Join(info.BackupsDirPath, steamUserId))
+		if err != nil {
+			return errors.New("failed to back up shortcuts file - " + err.Error())
+		}
+	}
+
+	return atomicfile.WriteFile(shortcutsFilePath, data, defaultShortcutsFileMode)
+}
+
+// backupShortcutsFile copies the shortcuts file to a timestamped file in
+// the backups directory. Nothing is copied if the file does not exist,
+// or if it is identical to the newest backup. Only the newest backups
+// are kept.
+func backupShortcutsFile(shortcutsFilePath string, backupsDirPath string) error {
+	current, err := ioutil.ReadFile(shortcutsFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	backups, err := shortcutsBackups(backupsDirPath)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) > 0 {
+		newest, err := ioutil.ReadFile(backups[len(backups)-1])
+		if err == nil && bytes.Equal(newest, current) {
+			return nil
+		}
+	}
+
+	err = os.MkdirAll(backupsDirPath, backupsDirMode)
+	if err != nil {
+		return err
+	}
+
+	backupFilePath := path.Join(backupsDirPath, shortcutsBackupPrefix +
+		time.Now().UTC().Format(shortcutsBackupTimeFmt) + shortcutsBackupSuffix)
+
+	err = atomicfile.WriteFile(backupFilePath, current, defaultShortcutsFileMode)
+	if err != nil {
+		return err
+	}
+
+	backups = append(backups, backupFilePath)
+
+	for len(backups) > maxShortcutsBackups {
+		err := os.Remove(backups[0])
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// shortcutsBackups returns the paths to the backups in the directory,
+// oldest first.
+func shortcutsBackups(backupsDirPath string) ([]string, error) {
+	infos, err := ioutil.ReadDir(backupsDirPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var backups []string
+
+	for _, fileInfo := range infos {
+		if fileInfo.IsDir() || !strings.HasPrefix(fileInfo.Name(), shortcutsBackupPrefix) ||
+			!strings.HasSuffix(fileInfo.Name(), shortcutsBackupSuffix) {
+			continue
+		}
+
+		backups = append(backups, path.Join(backupsDirPath, fileInfo.Name()))
+	}
+
+	// The timestamp format sorts lexically.
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+// RestoreShortcutsBackup replaces a Steam user's shortcuts file with
+// a backup. The Steam user is identified by the name of the backup's
+// parent directory. The current shortcuts file is backed up before
+// it is replaced. The ID of the restored Steam user is returned.
+func RestoreShortcutsBackup(info DataInfo, backupFilePath string) (string, error) {
+	backupFilePath = filepath.ToSlash(backupFilePath)
+
+	steamUserId := path.Base(path.Dir(backupFilePath))
+
+	_, ok := info.IdsToDirPaths[steamUserId]
+	if !ok {
+		return "", errors.New("the backup's parent directory '" + steamUserId +
+			"' is not the ID of a Steam user on this computer")
+	}
+
+	data, err := ioutil.ReadFile(backupFilePath)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = shortcuts.ReadVdfV1(bytes.NewReader(data))
+	if err != nil {
+		return "", errors.New("the backup is not a valid shortcuts file - " + err.Error())
+	}
+
+	err = replaceShortcutsFile(info, steamUserId, data)
+	if err != nil {
+		return "", err
+	}
+
+	return steamUserId, nil
+}
@@ -0,0 +1,60 @@
+package steamw
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stephen-fox/grundy/internal/atomicfile"
+)
+
+func TestBackupShortcutsFile(t *testing.T) {
+	dirPath, err := ioutil.TempDir("", "grundy-backup-test")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dirPath)
+
+	shortcutsFilePath := path.Join(dirPath, "shortcuts.vdf")
+	backupsDirPath := path.Join(dirPath, "backups")
+
+	err = backupShortcutsFile(shortcutsFilePath, backupsDirPath)
+	if err != nil {
+		t.Fatal("Backing up a missing file should not fail - " + err.Error())
+	}
+
+	for i := 0; i < maxShortcutsBackups + 5; i++ {
+		err := atomicfile.WriteFile(shortcutsFilePath, []byte(strconv.Itoa(i)), defaultShortcutsFileMode)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		// Identical contents should only be backed up once.
+		for j := 0; j < 2; j++ {
+			err = backupShortcutsFile(shortcutsFilePath, backupsDirPath)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+	}
+
+	backups, err := shortcutsBackups(backupsDirPath)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(backups) != maxShortcutsBackups {
+		t.Fatal("Expected", maxShortcutsBackups, "backups - got", len(backups))
+	}
+
+	newest, err := ioutil.ReadFile(backups[len(backups)-1])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if string(newest) != strconv.Itoa(maxShortcutsBackups + 4) {
+		t.Fatal("Newest backup has unexpected contents '" + string(newest) + "'")
+	}
+}